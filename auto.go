@@ -0,0 +1,159 @@
+package benchkit
+
+import (
+	"math"
+	"time"
+)
+
+// Calibration tells AutoTime how many times to run a benchmark.
+type Calibration struct {
+	// Warmup is the number of rounds to run before measuring anything.
+	// Their timings are discarded.
+	Warmup int
+	// Target is the wall time after which to stop measuring. It defaults to
+	// one second, and also bounds the time spent trying to reach TargetRSE.
+	Target time.Duration
+	// TargetRSE, if set, stops measuring once the relative standard error
+	// of the mean of every step is at most TargetRSE, e.g. 0.01 for 1%.
+	TargetRSE float64
+	// MinRounds is the least number of rounds to measure, which defaults
+	// to 2.
+	MinRounds int
+	// MaxRounds, if set, is the most number of rounds to measure.
+	MaxRounds int
+}
+
+func (c Calibration) withDefaults() Calibration {
+	if c.Target <= 0 {
+		c.Target = time.Second
+	}
+	if c.MinRounds <= 0 {
+		c.MinRounds = 2
+	}
+	return c
+}
+
+func (c Calibration) done(rounds int, elapsed time.Duration, rse float64) bool {
+	switch {
+	case c.MaxRounds > 0 && rounds >= c.MaxRounds:
+		return true
+	case rounds < c.MinRounds:
+		return false
+	case elapsed >= c.Target:
+		return true
+	default:
+		return c.TargetRSE > 0 && rse <= c.TargetRSE
+	}
+}
+
+// AutoResult contains the timings of an AutoTime benchmark, along with the
+// number of rounds it settled on.
+type AutoResult struct {
+	Time *TimeResult
+	// Warmup is the number of rounds that were discarded.
+	Warmup int
+	// Rounds is the number of rounds that were measured.
+	Rounds int
+	// RSE is the largest relative standard error of the mean of a step.
+	RSE float64
+}
+
+// AutoTime will track timings over exactly n steps, calling round over and
+// over until the Calibration is satisfied. Each call to round must run every
+// step once:
+//
+//	result, err := benchkit.AutoTime(n, benchkit.Calibration{Warmup: 10, TargetRSE: 0.01},
+//		func(each benchkit.BenchEach) error {
+//			for i, job := range thingsToDoManyTimes {
+//				each.Before(i)
+//				job()
+//				each.After(i)
+//			}
+//			return nil
+//		},
+//	)
+//
+// AutoTime stops at the first error returned by round, which it returns
+// along with the timings measured so far. If a warmup round fails, there
+// are no timings, and Warmup is the number of rounds that succeeded.
+func AutoTime(n int, c Calibration, round func(each BenchEach) error) (*AutoResult, error) {
+	c = c.withDefaults()
+
+	warmup := newTimeEach(n, c.Warmup, false)
+	for i := 0; i < c.Warmup; i++ {
+		if err := round(warmup); err != nil {
+			// nothing was measured yet
+			times := &TimeResult{N: n, Each: make([]TimeStep, n)}
+			return &AutoResult{Time: times, Warmup: i}, err
+		}
+	}
+
+	m := c.MaxRounds
+	if m <= 0 {
+		m = 100
+	}
+	kit, times := Time(n, m)
+	result := &AutoResult{Time: times, Warmup: c.Warmup}
+
+	kit.Setup()
+	kit.Starting()
+	each := kit.Each().(*timeEach)
+	stats := make([]runningStats, n)
+	start := time.Now()
+	var err error
+	for {
+		if err = round(each); err != nil {
+			break
+		}
+		result.Rounds++
+		if c.TargetRSE > 0 {
+			result.RSE = updateRSE(stats, each.after)
+		}
+		if c.done(result.Rounds, time.Since(start), result.RSE) {
+			break
+		}
+	}
+	kit.Teardown()
+
+	if c.TargetRSE <= 0 {
+		result.RSE = updateRSE(stats, each.after)
+	}
+	return result, err
+}
+
+// runningStats tracks the mean and variance of a step as samples come in,
+// using Welford's method.
+type runningStats struct {
+	seen int
+	mean float64
+	m2   float64
+}
+
+func (r *runningStats) add(x float64) {
+	r.seen++
+	delta := x - r.mean
+	r.mean += delta / float64(r.seen)
+	r.m2 += delta * (x - r.mean)
+}
+
+// rse is the relative standard error of the mean.
+func (r *runningStats) rse() float64 {
+	if r.seen < 2 || r.mean == 0 {
+		return math.Inf(1)
+	}
+	sd := math.Sqrt(r.m2 / float64(r.seen-1))
+	return sd / math.Sqrt(float64(r.seen)) / r.mean
+}
+
+// updateRSE adds the samples not seen yet to the stats of each step, and
+// returns the largest RSE of a step.
+func updateRSE(stats []runningStats, steps [][]time.Duration) float64 {
+	worst := 0.0
+	for i, samples := range steps {
+		for _, dur := range samples[stats[i].seen:] {
+			stats[i].add(float64(dur))
+		}
+		worst = math.Max(worst, stats[i].rse())
+	}
+	return worst
+}
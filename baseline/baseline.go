@@ -0,0 +1,160 @@
+// Package baseline saves the results of a benchmark as a baseline, and
+// checks later runs against it, with tolerances on how much each step may
+// regress. With package baselinetest, performance budgets can then live
+// next to unit tests:
+//
+//	func TestTarBudget(t *testing.T) {
+//		result := runTarBenchmark()
+//		baselinetest.Assert(t, "testdata/tar.baseline.json",
+//			baseline.New(result.Time, result.Memory),
+//			baseline.Relative(baseline.P90, 0.05),
+//			baseline.Absolute(baseline.HeapBytes, 1<<20).For("encode"),
+//		)
+//	}
+package baseline
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"time"
+
+	"github.com/aybabtme/benchkit"
+)
+
+// Version of the baseline files written by this package.
+const Version = 1
+
+// Baseline summarizes the steps of a run of a benchmark.
+type Baseline struct {
+	Version int    `json:"version"`
+	Steps   []Step `json:"steps"`
+}
+
+// Step summarizes a step of a run. Its durations summarize all the
+// durations of the step, outliers included, like the Summary of its
+// TimeStep.
+type Step struct {
+	// Name of the step, or its index if the steps aren't named.
+	Name  string        `json:"name"`
+	Count int           `json:"count"`
+	Min   time.Duration `json:"min_ns"`
+	Mean  time.Duration `json:"mean_ns"`
+	P50   time.Duration `json:"p50_ns"`
+	P90   time.Duration `json:"p90_ns"`
+	P99   time.Duration `json:"p99_ns"`
+	Max   time.Duration `json:"max_ns"`
+	// Memory is nil if the run had no memory results.
+	Memory *Memory `json:"memory,omitempty"`
+}
+
+// Memory used by a step, from the difference between the MemStats taken
+// before and after it.
+type Memory struct {
+	// AllocBytes are the bytes allocated by the step.
+	AllocBytes uint64 `json:"alloc_bytes"`
+	// Allocs is the number of allocations made by the step.
+	Allocs uint64 `json:"allocs"`
+	// HeapBytes is the growth of the live heap over the step, negative
+	// if it shrank.
+	HeapBytes int64 `json:"heap_bytes"`
+}
+
+// New summarizes the results of a run. Either of them may be nil. If both
+// are given, the steps of the memory results are matched by name if they
+// are named, otherwise by index.
+func New(t *benchkit.TimeResult, m *benchkit.MemResult) *Baseline {
+	b := &Baseline{Version: Version}
+	switch {
+	case t != nil:
+		for i := range t.Each {
+			sum := t.Each[i].Summary()
+			b.Steps = append(b.Steps, Step{
+				Name:  t.StepName(i),
+				Count: sum.Count,
+				Min:   sum.Min,
+				Mean:  sum.Mean,
+				P50:   sum.P50,
+				P90:   sum.P90,
+				P99:   sum.P99,
+				Max:   sum.Max,
+			})
+		}
+	case m != nil:
+		for i := 0; i < m.N; i++ {
+			b.Steps = append(b.Steps, Step{Name: m.StepName(i)})
+		}
+	}
+	if m == nil {
+		return b
+	}
+	for i := range b.Steps {
+		j := i
+		if len(m.Names) != 0 {
+			j = m.Index(b.Steps[i].Name)
+		}
+		if j < 0 || j >= m.N || j >= len(m.BeforeEach) || j >= len(m.AfterEach) {
+			continue
+		}
+		before, after := m.BeforeEach[j], m.AfterEach[j]
+		b.Steps[i].Memory = &Memory{
+			AllocBytes: after.TotalAlloc - before.TotalAlloc,
+			Allocs:     after.Mallocs - before.Mallocs,
+			HeapBytes:  int64(after.HeapAlloc) - int64(before.HeapAlloc),
+		}
+	}
+	return b
+}
+
+// Step returns the named step, or nil.
+func (b *Baseline) Step(name string) *Step {
+	for i := range b.Steps {
+		if b.Steps[i].Name == name {
+			return &b.Steps[i]
+		}
+	}
+	return nil
+}
+
+// Write the baseline to w, as JSON.
+func (b *Baseline) Write(w io.Writer) error {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(b)
+}
+
+// Read a baseline written by Write.
+func Read(r io.Reader) (*Baseline, error) {
+	b := &Baseline{}
+	if err := json.NewDecoder(r).Decode(b); err != nil {
+		return nil, fmt.Errorf("reading baseline: %v", err)
+	}
+	if b.Version != Version {
+		return nil, fmt.Errorf("unsupported baseline version %d, want %d", b.Version, Version)
+	}
+	return b, nil
+}
+
+// Save the baseline to a file.
+func (b *Baseline) Save(filename string) error {
+	f, err := os.Create(filename)
+	if err != nil {
+		return err
+	}
+	if err := b.Write(f); err != nil {
+		_ = f.Close()
+		return err
+	}
+	return f.Close()
+}
+
+// Load a baseline from a file.
+func Load(filename string) (*Baseline, error) {
+	f, err := os.Open(filename)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	return Read(f)
+}
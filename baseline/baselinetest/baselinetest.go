@@ -0,0 +1,37 @@
+// Package baselinetest checks the runs of a benchmark against a baseline
+// from tests. It registers the -benchkit.update flag, so only import it
+// from tests.
+package baselinetest
+
+import (
+	"errors"
+	"flag"
+	"io/fs"
+	"testing"
+
+	"github.com/aybabtme/benchkit/baseline"
+)
+
+var update = flag.Bool("benchkit.update", false, "overwrite the baselines of benchkit with the current runs")
+
+// Assert fails the test for each tolerance that the current run violates,
+// against the baseline saved in a file. If the file doesn't exist yet, or
+// the test runs with the -benchkit.update flag, the current run is saved
+// as the new baseline instead.
+func Assert(t testing.TB, filename string, current *baseline.Baseline, tols ...baseline.Tolerance) {
+	t.Helper()
+	base, err := baseline.Load(filename)
+	if *update || errors.Is(err, fs.ErrNotExist) {
+		if err := current.Save(filename); err != nil {
+			t.Fatalf("saving baseline: %v", err)
+		}
+		t.Logf("saved baseline %s", filename)
+		return
+	}
+	if err != nil {
+		t.Fatalf("loading baseline: %v", err)
+	}
+	for _, v := range base.Check(current, tols...) {
+		t.Error(v)
+	}
+}
@@ -0,0 +1,202 @@
+package baseline
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"time"
+)
+
+// Metric of a step that a Tolerance bounds.
+type Metric int
+
+// Metrics of a step. The durations are in nanoseconds, the memory in bytes
+// or allocations.
+const (
+	Min Metric = iota
+	Mean
+	P50
+	P90
+	P99
+	Max
+	AllocBytes
+	Allocs
+	HeapBytes
+)
+
+func (m Metric) String() string {
+	switch m {
+	case Min:
+		return "min"
+	case Mean:
+		return "mean"
+	case P50:
+		return "p50"
+	case P90:
+		return "p90"
+	case P99:
+		return "p99"
+	case Max:
+		return "max"
+	case AllocBytes:
+		return "alloc bytes"
+	case Allocs:
+		return "allocs"
+	case HeapBytes:
+		return "heap bytes"
+	}
+	return "Metric(" + strconv.Itoa(int(m)) + ")"
+}
+
+// value of the metric for the step, if the step has it.
+func (m Metric) value(step *Step) (float64, bool) {
+	switch m {
+	case Min:
+		return float64(step.Min), step.Count != 0
+	case Mean:
+		return float64(step.Mean), step.Count != 0
+	case P50:
+		return float64(step.P50), step.Count != 0
+	case P90:
+		return float64(step.P90), step.Count != 0
+	case P99:
+		return float64(step.P99), step.Count != 0
+	case Max:
+		return float64(step.Max), step.Count != 0
+	}
+	if step.Memory == nil {
+		return 0, false
+	}
+	switch m {
+	case AllocBytes:
+		return float64(step.Memory.AllocBytes), true
+	case Allocs:
+		return float64(step.Memory.Allocs), true
+	case HeapBytes:
+		return float64(step.Memory.HeapBytes), true
+	}
+	return 0, false
+}
+
+func (m Metric) format(v float64) string {
+	switch m {
+	case AllocBytes, Allocs, HeapBytes:
+		return strconv.FormatFloat(v, 'f', -1, 64)
+	}
+	return time.Duration(v).String()
+}
+
+// Tolerance bounds how much a metric of a step may regress from the
+// baseline. If both are set, the larger of the two regressions is allowed.
+type Tolerance struct {
+	// Step the tolerance applies to, or all the steps if empty. The
+	// tolerance of a step overrides the one of all the steps.
+	Step   string
+	Metric Metric
+	// Relative regression allowed, such as 0.05 for 5%.
+	Relative float64
+	// Absolute regression allowed, in the unit of the metric.
+	Absolute float64
+}
+
+// Relative allows a metric of all the steps to regress by a fraction of
+// the baseline, such as 0.05 for 5%.
+func Relative(m Metric, frac float64) Tolerance {
+	return Tolerance{Metric: m, Relative: frac}
+}
+
+// Absolute allows a metric of all the steps to regress by an amount, in the
+// unit of the metric, such as 1<<20 for 1 MB of HeapBytes.
+func Absolute(m Metric, v float64) Tolerance {
+	return Tolerance{Metric: m, Absolute: v}
+}
+
+// For applies the tolerance to the named step only.
+func (t Tolerance) For(step string) Tolerance {
+	t.Step = step
+	return t
+}
+
+// limit is the highest value of the metric allowed, given its baseline.
+func (t Tolerance) limit(base float64) float64 {
+	return base + math.Max(t.Relative*math.Abs(base), t.Absolute)
+}
+
+// Violation of a tolerance by a step.
+type Violation struct {
+	Step      string
+	Metric    Metric
+	Tolerance Tolerance
+	// Baseline and Current values of the metric, and the Limit it
+	// exceeded.
+	Baseline float64
+	Current  float64
+	Limit    float64
+}
+
+func (v Violation) Error() string {
+	change := ""
+	if v.Baseline != 0 {
+		change = fmt.Sprintf(" (%+.1f%%)", 100*(v.Current-v.Baseline)/math.Abs(v.Baseline))
+	}
+	return fmt.Sprintf("step %s: %v regressed from %s to %s%s, above the limit of %s",
+		v.Step, v.Metric,
+		v.Metric.format(v.Baseline), v.Metric.format(v.Current), change,
+		v.Metric.format(v.Limit),
+	)
+}
+
+// Check the steps of a run against the baseline, returning the tolerances
+// they violate. Metrics without a tolerance aren't checked, and neither are
+// steps or metrics missing from either the baseline or the run.
+func (b *Baseline) Check(current *Baseline, tols ...Tolerance) []Violation {
+	var violations []Violation
+	for i := range b.Steps {
+		base := &b.Steps[i]
+		cur := current.Step(base.Name)
+		if cur == nil {
+			continue
+		}
+		for _, tol := range tolerancesFor(base.Name, tols) {
+			bv, ok := tol.Metric.value(base)
+			if !ok {
+				continue
+			}
+			cv, ok := tol.Metric.value(cur)
+			if !ok {
+				continue
+			}
+			if limit := tol.limit(bv); cv > limit {
+				violations = append(violations, Violation{
+					Step:      base.Name,
+					Metric:    tol.Metric,
+					Tolerance: tol,
+					Baseline:  bv,
+					Current:   cv,
+					Limit:     limit,
+				})
+			}
+		}
+	}
+	return violations
+}
+
+// tolerancesFor returns the tolerances of a step, one per metric at most.
+func tolerancesFor(step string, tols []Tolerance) []Tolerance {
+	var out []Tolerance
+	seen := make(map[Metric]int)
+	for _, tol := range tols {
+		if tol.Step != "" && tol.Step != step {
+			continue
+		}
+		i, ok := seen[tol.Metric]
+		switch {
+		case !ok:
+			seen[tol.Metric] = len(out)
+			out = append(out, tol)
+		case tol.Step != "":
+			out[i] = tol
+		}
+	}
+	return out
+}
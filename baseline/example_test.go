@@ -0,0 +1,61 @@
+package baseline_test
+
+import (
+	"bytes"
+	"fmt"
+	"runtime"
+	"time"
+
+	"github.com/aybabtme/benchkit"
+	"github.com/aybabtme/benchkit/baseline"
+)
+
+// run fakes the results of a benchmark, whose steps take about the given
+// durations and grow the heap by the given bytes.
+func run(names []string, typical []time.Duration, heap []uint64) (*benchkit.TimeResult, *benchkit.MemResult) {
+	t := &benchkit.TimeResult{N: len(typical), Names: names}
+	m := &benchkit.MemResult{N: len(heap), Names: names}
+	for i, dur := range typical {
+		var durs []time.Duration
+		for j := 0; j < 100; j++ {
+			durs = append(durs, dur+time.Duration(j)*dur/100)
+		}
+		t.Each = append(t.Each, benchkit.NewTimeStep(durs, benchkit.NoFilter))
+
+		before := &runtime.MemStats{HeapAlloc: 1 << 20}
+		after := &runtime.MemStats{HeapAlloc: 1<<20 + heap[i]}
+		m.BeforeEach = append(m.BeforeEach, before)
+		m.AfterEach = append(m.AfterEach, after)
+	}
+	return t, m
+}
+
+func ExampleBaseline_Check() {
+	names := []string{"parse", "encode"}
+	base := baseline.New(run(names,
+		[]time.Duration{time.Millisecond, 2 * time.Millisecond},
+		[]uint64{1 << 10, 1 << 20},
+	))
+
+	// baselines are usually saved to a file, and loaded by later runs
+	buf := bytes.NewBuffer(nil)
+	_ = base.Write(buf)
+	base, _ = baseline.Read(buf)
+
+	current := baseline.New(run(names,
+		[]time.Duration{1100 * time.Microsecond, 2 * time.Millisecond},
+		[]uint64{1 << 10, 3 << 20},
+	))
+
+	violations := base.Check(current,
+		baseline.Relative(baseline.P90, 0.05),
+		baseline.Absolute(baseline.HeapBytes, 1<<20),
+	)
+	for _, v := range violations {
+		fmt.Println(v)
+	}
+
+	// Output:
+	// step parse: p90 regressed from 1.891ms to 2.0801ms (+10.0%), above the limit of 1.98555ms
+	// step encode: heap bytes regressed from 1048576 to 3145728 (+200.0%), above the limit of 2097152
+}
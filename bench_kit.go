@@ -1,5 +1,7 @@
 package benchkit
 
+import "time"
+
 // BenchKit tracks metrics about your benchmark.
 type BenchKit interface {
 	// Setup must be called before doing any benchmark allocation.
@@ -9,7 +11,10 @@ type BenchKit interface {
 	Starting()
 	// Teardown must be called once your benchmark is done.
 	Teardown()
-	// Each gives an object that tracks each step of your work.
+	// Each gives an object that tracks each step of your work. Kits may
+	// return a new BenchEach on every call, so that each goroutine gets
+	// its own: keep the one you got, and call After on the same BenchEach
+	// as the Before it matches.
 	Each() BenchEach
 }
 
@@ -20,3 +25,29 @@ type BenchEach interface {
 	// After must be called _after_ finishing a unit of work.
 	After(id int)
 }
+
+// BenchTokens is implemented by the BenchEach of kits that can track many
+// units of work in flight on the same step. Get it with a type assertion:
+//
+//	tokens := kit.Each().(BenchTokens)
+type BenchTokens interface {
+	BenchEach
+	// Begin must be called _before_ starting a unit of work. Give the
+	// returned token to End once the work is done.
+	Begin(id int) Token
+	// End must be called _after_ finishing the unit of work started with
+	// tok. It can be called on another BenchEach of the same kit than the
+	// one that started tok, for instance the one of another goroutine.
+	End(tok Token)
+}
+
+// Token is a unit of work in flight, started by BenchTokens.Begin.
+type Token struct {
+	id    int
+	start time.Time
+	// inner are the tokens of the kits combined by Multi.
+	inner []Token
+}
+
+// ID is the step to which the unit of work belongs.
+func (t Token) ID() int { return t.id }
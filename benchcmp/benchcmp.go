@@ -0,0 +1,420 @@
+// Package benchcmp compares the results of two runs of a benchmark, step
+// by step, to tell which steps got significantly slower or faster.
+package benchcmp
+
+import (
+	"fmt"
+	"io"
+	"math"
+	"sort"
+	"strconv"
+	"text/tabwriter"
+	"time"
+
+	"github.com/aybabtme/benchkit"
+	"github.com/aybabtme/benchkit/internal/stats"
+)
+
+// Test tells whether the durations of a step differ between two runs.
+type Test int
+
+const (
+	// MannWhitney is the Mann-Whitney U test, which compares the ranks of
+	// the durations and makes no assumption about their distribution.
+	MannWhitney Test = iota
+	// Welch is Welch's t-test, which compares the means of the durations.
+	Welch
+)
+
+func (t Test) String() string {
+	switch t {
+	case MannWhitney:
+		return "Mann-Whitney U"
+	case Welch:
+		return "Welch's t-test"
+	}
+	return "Test(" + strconv.Itoa(int(t)) + ")"
+}
+
+// Verdict on a step.
+type Verdict int
+
+const (
+	// Unchanged steps don't differ significantly.
+	Unchanged Verdict = iota
+	// Improvement is a step significantly faster in the new run.
+	Improvement
+	// Regression is a step significantly slower in the new run.
+	Regression
+)
+
+func (v Verdict) String() string {
+	switch v {
+	case Unchanged:
+		return "unchanged"
+	case Improvement:
+		return "improvement"
+	case Regression:
+		return "regression"
+	}
+	return "Verdict(" + strconv.Itoa(int(v)) + ")"
+}
+
+// Config of a comparison. The zero value is ready to use.
+type Config struct {
+	// Alpha is the significance level of the tests, which defaults to
+	// 0.05. Confidence intervals are given at the level 1-Alpha.
+	Alpha float64
+	// Test decides whether the steps differ, MannWhitney by default.
+	Test Test
+	// Threshold is the smallest relative change of the mean that is
+	// flagged, such as 0.02 for 2%. Smaller changes are Unchanged, even
+	// if significant.
+	Threshold float64
+	// Significant compares only the Significant durations of the steps,
+	// rather than all of them. The outliers left out by the filter of the
+	// kit, such as a slow tail, are then ignored.
+	Significant bool
+}
+
+// TestResult is the outcome of a statistical test.
+type TestResult struct {
+	// Statistic of the test: U for Mann-Whitney, t for Welch.
+	Statistic float64
+	// P is the probability of a difference at least as large if the steps
+	// had the same distribution. It is NaN if the test couldn't be done.
+	P float64
+}
+
+// Step compares a step between the old and the new run. The statistics
+// consider all the durations of the steps, or their Significant durations
+// if so configured. Only the count, mean and standard deviation of the
+// steps of a HistTime kit are known, on which Mann-Whitney can't be done.
+type Step struct {
+	// Name of the step, or its index if the steps aren't named.
+	Name     string
+	Old, New *benchkit.TimeStep
+	// OldMean and NewMean are the mean durations.
+	OldMean, NewMean time.Duration
+
+	// Delta is the relative change of the mean, such as 0.1 for 10%
+	// slower. DeltaLo and DeltaHi are its confidence interval.
+	Delta, DeltaLo, DeltaHi float64
+
+	MannWhitney TestResult
+	Welch       TestResult
+	// CohensD is the change of the mean in pooled standard deviations.
+	CohensD float64
+	// CliffsDelta is the probability that a new duration is longer than
+	// an old one, minus the probability that it's shorter, from -1 to 1.
+	CliffsDelta float64
+
+	// P of the Test of the comparison.
+	P       float64
+	Verdict Verdict
+}
+
+// Result of a comparison.
+type Result struct {
+	Alpha       float64
+	Test        Test
+	Significant bool
+	Steps       []Step
+}
+
+// Regressions returns the steps that got slower.
+func (r *Result) Regressions() []Step { return r.filter(Regression) }
+
+// Improvements returns the steps that got faster.
+func (r *Result) Improvements() []Step { return r.filter(Improvement) }
+
+func (r *Result) filter(v Verdict) []Step {
+	var steps []Step
+	for _, step := range r.Steps {
+		if step.Verdict == v {
+			steps = append(steps, step)
+		}
+	}
+	return steps
+}
+
+// Compare the steps of two runs. Steps are matched by name if both runs
+// named them, otherwise by index. Steps found in only one run are left
+// out.
+func Compare(old, new *benchkit.TimeResult, c Config) *Result {
+	if c.Alpha <= 0 || c.Alpha >= 1 {
+		c.Alpha = 0.05
+	}
+	res := &Result{Alpha: c.Alpha, Test: c.Test, Significant: c.Significant}
+	named := len(old.Names) != 0 && len(new.Names) != 0
+	for i := range old.Each {
+		name, j := strconv.Itoa(i), i
+		if named {
+			if i >= len(old.Names) {
+				break
+			}
+			name, j = old.Names[i], new.Index(old.Names[i])
+		}
+		if j < 0 || j >= len(new.Each) {
+			continue
+		}
+		res.Steps = append(res.Steps, compareStep(name, &old.Each[i], &new.Each[j], c))
+	}
+	return res
+}
+
+func compareStep(name string, old, new *benchkit.TimeStep, c Config) Step {
+	x, y := durations(old, c.Significant), durations(new, c.Significant)
+	a, b := newMoments(old, x), newMoments(new, y)
+	step := Step{
+		Name:        name,
+		Old:         old,
+		New:         new,
+		OldMean:     time.Duration(math.Round(a.mean)),
+		NewMean:     time.Duration(math.Round(b.mean)),
+		MannWhitney: TestResult{Statistic: math.NaN(), P: math.NaN()},
+		CliffsDelta: math.NaN(),
+	}
+	if len(x) != 0 && len(y) != 0 {
+		u, p := mannWhitney(x, y)
+		step.MannWhitney = TestResult{Statistic: u, P: p}
+		step.CliffsDelta = 2*u/(a.n*b.n) - 1
+	}
+	t, df, p := welch(a, b)
+	step.Welch = TestResult{Statistic: t, P: p}
+
+	step.Delta, step.DeltaLo, step.DeltaHi = delta(a, b, df, c.Alpha)
+	if pooled := math.Sqrt(((a.n-1)*a.variance + (b.n-1)*b.variance) / (a.n + b.n - 2)); pooled > 0 {
+		step.CohensD = (b.mean - a.mean) / pooled
+	}
+
+	slower := step.Delta > 0
+	step.P = step.Welch.P
+	if c.Test == MannWhitney && !math.IsNaN(step.MannWhitney.P) {
+		step.P = step.MannWhitney.P
+		slower = step.CliffsDelta > 0
+	}
+	switch {
+	case !(step.P < c.Alpha), math.Abs(step.Delta) < c.Threshold:
+		step.Verdict = Unchanged
+	case slower:
+		step.Verdict = Regression
+	default:
+		step.Verdict = Improvement
+	}
+	return step
+}
+
+// moments of the durations of a step, in nanoseconds.
+type moments struct {
+	n, mean, variance float64
+}
+
+// durations of a step that are compared: all of them, or only the
+// significant ones.
+func durations(step *benchkit.TimeStep, significant bool) []time.Duration {
+	if significant {
+		return step.Significant
+	}
+	return step.Samples()
+}
+
+func newMoments(step *benchkit.TimeStep, durs []time.Duration) moments {
+	if len(step.Samples()) == 0 {
+		// only a histogram: the SD of the step is the one of the population
+		n := float64(step.Count())
+		sd := float64(step.SD)
+		m := moments{n: n, mean: float64(step.Avg)}
+		if n > 1 {
+			m.variance = sd * sd * n / (n - 1)
+		}
+		return m
+	}
+	m := moments{n: float64(len(durs))}
+	if m.n == 0 {
+		return m
+	}
+	for _, dur := range durs {
+		m.mean += float64(dur)
+	}
+	m.mean /= m.n
+	if m.n < 2 {
+		return m
+	}
+	for _, dur := range durs {
+		d := float64(dur) - m.mean
+		m.variance += d * d
+	}
+	m.variance /= m.n - 1
+	return m
+}
+
+// mannWhitney returns the U statistic of y against x, which counts the
+// pairs where y is longer than x, and its two-sided p-value from the normal
+// approximation with tie and continuity corrections.
+func mannWhitney(x, y []time.Duration) (u, p float64) {
+	type obs struct {
+		dur  time.Duration
+		ofY  bool
+		rank float64
+	}
+	all := make([]obs, 0, len(x)+len(y))
+	for _, dur := range x {
+		all = append(all, obs{dur: dur})
+	}
+	for _, dur := range y {
+		all = append(all, obs{dur: dur, ofY: true})
+	}
+	sort.Slice(all, func(i, j int) bool { return all[i].dur < all[j].dur })
+
+	var ranksY, ties float64
+	for i := 0; i < len(all); {
+		j := i
+		for j < len(all) && all[j].dur == all[i].dur {
+			j++
+		}
+		// tied durations share the mean of their ranks
+		rank := float64(i+j+1) / 2
+		for k := i; k < j; k++ {
+			if all[k].ofY {
+				ranksY += rank
+			}
+		}
+		t := float64(j - i)
+		ties += t*t*t - t
+		i = j
+	}
+
+	n1, n2 := float64(len(x)), float64(len(y))
+	n := n1 + n2
+	u = ranksY - n2*(n2+1)/2
+	sigma := math.Sqrt(n1 * n2 / 12 * ((n + 1) - ties/(n*(n-1))))
+	if sigma == 0 {
+		return u, 1
+	}
+	dev := math.Max(0, math.Abs(u-n1*n2/2)-0.5)
+	return u, 2 * (1 - stats.NormCDF(dev/sigma))
+}
+
+// welch returns the t statistic of b against a, its degrees of freedom
+// and its two-sided p-value.
+func welch(a, b moments) (t, df, p float64) {
+	if a.n < 2 || b.n < 2 {
+		return math.NaN(), math.NaN(), math.NaN()
+	}
+	va, vb := a.variance/a.n, b.variance/b.n
+	se := math.Sqrt(va + vb)
+	if se == 0 {
+		if a.mean == b.mean {
+			return 0, math.Inf(1), 1
+		}
+		return math.Copysign(math.Inf(1), b.mean-a.mean), math.Inf(1), 0
+	}
+	t = (b.mean - a.mean) / se
+	df = (va + vb) * (va + vb) / (va*va/(a.n-1) + vb*vb/(b.n-1))
+	return t, df, 2 * (1 - stats.StudentCDF(math.Abs(t), df))
+}
+
+// delta returns the relative change of the mean, and its confidence
+// interval from the delta method.
+func delta(a, b moments, df, alpha float64) (d, lo, hi float64) {
+	if a.mean == 0 {
+		return math.NaN(), math.NaN(), math.NaN()
+	}
+	ratio := b.mean / a.mean
+	d = ratio - 1
+	if math.IsNaN(df) || b.mean == 0 {
+		return d, math.NaN(), math.NaN()
+	}
+	se := ratio * math.Sqrt(a.variance/a.n/(a.mean*a.mean)+b.variance/b.n/(b.mean*b.mean))
+	margin := stats.StudentQuantile(1-alpha/2, df) * se
+	if se == 0 {
+		margin = 0
+	}
+	return d, d - margin, d + margin
+}
+
+// WriteTo writes a table of the comparison, in the spirit of benchstat:
+// the mean and relative standard deviation of each step in both runs, and
+// the change of the mean with its confidence interval if it's flagged, or
+// ~ if it's not.
+func (r *Result) WriteTo(w io.Writer) (int64, error) {
+	cw := &countWriter{w: w}
+	tw := tabwriter.NewWriter(cw, 0, 4, 2, ' ', 0)
+	fmt.Fprintf(tw, "step\told time/step\tnew time/step\tdelta\tp\tn\n")
+	var logOld, logNew, steps float64
+	for _, step := range r.Steps {
+		old, new := r.moments(step.Old), r.moments(step.New)
+		fmt.Fprintf(tw, "%s\t%s\t%s\t%s\t%.3f\t%.0f+%.0f\n",
+			step.Name,
+			meanSD(old), meanSD(new),
+			deltaCI(step),
+			step.P, old.n, new.n,
+		)
+		if step.OldMean > 0 && step.NewMean > 0 {
+			logOld += math.Log(float64(step.OldMean))
+			logNew += math.Log(float64(step.NewMean))
+			steps++
+		}
+	}
+	if steps > 1 {
+		geoOld, geoNew := math.Exp(logOld/steps), math.Exp(logNew/steps)
+		fmt.Fprintf(tw, "[geomean]\t%s\t%s\t%+.2f%%\n",
+			round(geoOld), round(geoNew), (geoNew/geoOld-1)*100)
+	}
+	if err := tw.Flush(); err != nil {
+		return cw.n, err
+	}
+	return cw.n, cw.err
+}
+
+// moments of the durations of a step that were compared.
+func (r *Result) moments(step *benchkit.TimeStep) moments {
+	return newMoments(step, durations(step, r.Significant))
+}
+
+func meanSD(m moments) string {
+	if m.mean == 0 {
+		return round(0).String()
+	}
+	return fmt.Sprintf("%s ± %.0f%%", round(m.mean), 100*math.Sqrt(m.variance)/m.mean)
+}
+
+func deltaCI(step Step) string {
+	if step.Verdict == Unchanged {
+		return "~"
+	}
+	if math.IsNaN(step.DeltaLo) {
+		return fmt.Sprintf("%+.2f%%", 100*step.Delta)
+	}
+	return fmt.Sprintf("%+.2f%% [%+.2f%%, %+.2f%%]",
+		100*step.Delta, 100*step.DeltaLo, 100*step.DeltaHi)
+}
+
+// round a duration to 3 significant digits.
+func round(ns float64) time.Duration {
+	if ns <= 0 {
+		return 0
+	}
+	scale := math.Pow(10, math.Floor(math.Log10(ns))-2)
+	if scale < 1 {
+		scale = 1
+	}
+	return time.Duration(math.Round(ns/scale) * scale)
+}
+
+type countWriter struct {
+	w   io.Writer
+	n   int64
+	err error
+}
+
+func (c *countWriter) Write(p []byte) (int, error) {
+	if c.err != nil {
+		return 0, c.err
+	}
+	n, err := c.w.Write(p)
+	c.n += int64(n)
+	c.err = err
+	return n, err
+}
@@ -0,0 +1,69 @@
+package benchcmp_test
+
+import (
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/aybabtme/benchkit"
+	"github.com/aybabtme/benchkit/benchcmp"
+)
+
+// run fakes the result of a benchmark, whose steps take about the given
+// durations.
+func run(names []string, typical ...time.Duration) *benchkit.TimeResult {
+	res := &benchkit.TimeResult{N: len(typical), Names: names}
+	for _, dur := range typical {
+		var durs []time.Duration
+		for i := 0; i < 50; i++ {
+			jitter := time.Duration(i*37%50) * dur / 500
+			durs = append(durs, dur+jitter)
+		}
+		// filtered like the steps of a kit, by the DefaultFilter
+		res.Each = append(res.Each, benchkit.NewTimeStep(durs, nil))
+	}
+	return res
+}
+
+func ExampleCompare() {
+	names := []string{"parse", "validate", "encode"}
+	old := run(names, 1*time.Millisecond, 300*time.Microsecond, 2*time.Millisecond)
+	new := run(names, 1200*time.Microsecond, 300*time.Microsecond, 1500*time.Microsecond)
+
+	cmp := benchcmp.Compare(old, new, benchcmp.Config{})
+	_, _ = cmp.WriteTo(os.Stdout)
+
+	for _, step := range cmp.Regressions() {
+		fmt.Printf("%s got %.0f%% slower\n", step.Name, 100*step.Delta)
+	}
+
+	// Output:
+	// step       old time/step  new time/step  delta                       p      n
+	// parse      1.05ms ± 3%    1.26ms ± 3%    +20.00% [+18.68%, +21.32%]  0.000  50+50
+	// validate   315µs ± 3%     315µs ± 3%     ~                           1.000  50+50
+	// encode     2.1ms ± 3%     1.57ms ± 3%    -25.00% [-25.83%, -24.17%]  0.000  50+50
+	// [geomean]  885µs          854µs          -3.45%
+	// parse got 20% slower
+}
+
+func ExampleConfig_significant() {
+	names := []string{"lookup"}
+	old := run(names, 1*time.Millisecond)
+	new := run(names, 1*time.Millisecond)
+	// the slowest tenth of the new lookups got three times slower, which
+	// the DefaultFilter mostly leaves out of the significant durations
+	slow := new.Each[0].Samples()
+	for i := len(slow) * 9 / 10; i < len(slow); i++ {
+		slow[i] *= 3
+	}
+	new.Each[0] = benchkit.NewTimeStep(slow, nil)
+
+	all := benchcmp.Compare(old, new, benchcmp.Config{Test: benchcmp.Welch})
+	significant := benchcmp.Compare(old, new, benchcmp.Config{Test: benchcmp.Welch, Significant: true})
+	fmt.Println("all durations:", all.Steps[0].Verdict)
+	fmt.Println("significant durations:", significant.Steps[0].Verdict)
+
+	// Output:
+	// all durations: regression
+	// significant durations: unchanged
+}
@@ -0,0 +1,234 @@
+// Package benchcsv writes the results of benchkit as CSV or TSV tables, to
+// be opened in spreadsheets or data frames. Durations are written in
+// nanoseconds, memory in bytes.
+package benchcsv
+
+import (
+	"encoding/csv"
+	"fmt"
+	"io"
+	"runtime"
+	"strconv"
+	"strings"
+
+	"github.com/aybabtme/benchkit"
+)
+
+// Config of a table. The zero value writes CSV with the default columns of
+// the table.
+type Config struct {
+	// Comma separates the fields, ',' by default. Use '\t' for TSV.
+	Comma rune
+	// Columns to write, in order. Each table has its default columns.
+	Columns []string
+}
+
+// StepColumns are the columns of WriteSteps, which writes them all by
+// default. They summarize all the durations of the step, outliers
+// included, like its Summary.
+var StepColumns = []string{"step", "count", "min_ns", "p50_ns", "p90_ns", "p99_ns", "max_ns", "mean_ns", "sd_ns"}
+
+// SampleColumns are the columns of WriteSamples, which writes them all by
+// default. The outlier column is empty for significant durations, and
+// "low" or "high" for the others.
+var SampleColumns = []string{"step", "sample", "duration_ns", "outlier"}
+
+// MemColumns are the default columns of WriteMemory. Each field of
+// runtime.MemStats, in snake case, has three columns: the field before
+// the step with the suffix _before, after the step with _after, and the
+// difference between them with _delta.
+var MemColumns = []string{"step", "total_alloc_delta", "mallocs_delta", "frees_delta", "heap_alloc_delta", "heap_objects_delta", "num_gc_delta"}
+
+// WriteSteps writes a row of summary statistics per step.
+func WriteSteps(w io.Writer, t *benchkit.TimeResult, c Config) error {
+	cols, err := c.columns(StepColumns, func(col string) bool {
+		return contains(StepColumns, col)
+	})
+	if err != nil {
+		return err
+	}
+	return c.write(w, cols, func(row func(values map[string]string) error) error {
+		for i := range t.Each {
+			sum := t.Each[i].Summary()
+			err := row(map[string]string{
+				"step":    t.StepName(i),
+				"count":   strconv.Itoa(sum.Count),
+				"min_ns":  itoa(int64(sum.Min)),
+				"p50_ns":  itoa(int64(sum.P50)),
+				"p90_ns":  itoa(int64(sum.P90)),
+				"p99_ns":  itoa(int64(sum.P99)),
+				"max_ns":  itoa(int64(sum.Max)),
+				"mean_ns": itoa(int64(sum.Mean)),
+				"sd_ns":   itoa(int64(sum.SD)),
+			})
+			if err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// WriteSamples writes a row per duration of each step, in increasing order
+// within a step. The steps of a HistTime kit have no samples to write.
+func WriteSamples(w io.Writer, t *benchkit.TimeResult, c Config) error {
+	cols, err := c.columns(SampleColumns, func(col string) bool {
+		return contains(SampleColumns, col)
+	})
+	if err != nil {
+		return err
+	}
+	return c.write(w, cols, func(row func(values map[string]string) error) error {
+		for i := range t.Each {
+			step := &t.Each[i]
+			name := t.StepName(i)
+			lows := len(step.Low.Durations)
+			highs := len(step.Samples()) - len(step.High.Durations)
+			for j, dur := range step.Samples() {
+				outlier := ""
+				switch {
+				case j < lows:
+					outlier = "low"
+				case j >= highs:
+					outlier = "high"
+				}
+				err := row(map[string]string{
+					"step":        name,
+					"sample":      strconv.Itoa(j),
+					"duration_ns": itoa(int64(dur)),
+					"outlier":     outlier,
+				})
+				if err != nil {
+					return err
+				}
+			}
+		}
+		return nil
+	})
+}
+
+// WriteMemory writes a row of MemStats per step, before and after it.
+func WriteMemory(w io.Writer, m *benchkit.MemResult, c Config) error {
+	cols, err := c.columns(MemColumns, func(col string) bool {
+		_, _, ok := memColumn(col)
+		return col == "step" || ok
+	})
+	if err != nil {
+		return err
+	}
+	return c.write(w, cols, func(row func(values map[string]string) error) error {
+		for i := 0; i < m.N && i < len(m.BeforeEach) && i < len(m.AfterEach); i++ {
+			before, after := m.BeforeEach[i], m.AfterEach[i]
+			values := map[string]string{"step": m.StepName(i)}
+			for _, col := range cols {
+				field, suffix, ok := memColumn(col)
+				if !ok {
+					continue
+				}
+				b, a := field(before), field(after)
+				switch suffix {
+				case "_before":
+					values[col] = strconv.FormatUint(b, 10)
+				case "_after":
+					values[col] = strconv.FormatUint(a, 10)
+				case "_delta":
+					values[col] = itoa(int64(a - b))
+				}
+			}
+			if err := row(values); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// columns returns the columns to write, making sure they're valid.
+func (c Config) columns(defaults []string, valid func(col string) bool) ([]string, error) {
+	if len(c.Columns) == 0 {
+		return defaults, nil
+	}
+	for _, col := range c.Columns {
+		if !valid(col) {
+			return nil, fmt.Errorf("benchcsv: unknown column %q", col)
+		}
+	}
+	return c.Columns, nil
+}
+
+// write a header with the columns, then the rows given by rows.
+func (c Config) write(w io.Writer, cols []string, rows func(row func(values map[string]string) error) error) error {
+	cw := csv.NewWriter(w)
+	if c.Comma != 0 {
+		cw.Comma = c.Comma
+	}
+	if err := cw.Write(cols); err != nil {
+		return err
+	}
+	record := make([]string, len(cols))
+	err := rows(func(values map[string]string) error {
+		for i, col := range cols {
+			record[i] = values[col]
+		}
+		return cw.Write(record)
+	})
+	if err != nil {
+		return err
+	}
+	cw.Flush()
+	return cw.Error()
+}
+
+// memColumn finds the field of MemStats of a column, along with the
+// suffix of the column.
+func memColumn(col string) (field func(*runtime.MemStats) uint64, suffix string, ok bool) {
+	for _, suffix := range []string{"_before", "_after", "_delta"} {
+		if name := strings.TrimSuffix(col, suffix); name != col {
+			field, ok := memFields[name]
+			return field, suffix, ok
+		}
+	}
+	return nil, "", false
+}
+
+// memFields are the counters of MemStats, named like in the JSON schema
+// of benchkit.
+var memFields = map[string]func(*runtime.MemStats) uint64{
+	"alloc":          func(m *runtime.MemStats) uint64 { return m.Alloc },
+	"total_alloc":    func(m *runtime.MemStats) uint64 { return m.TotalAlloc },
+	"sys":            func(m *runtime.MemStats) uint64 { return m.Sys },
+	"lookups":        func(m *runtime.MemStats) uint64 { return m.Lookups },
+	"mallocs":        func(m *runtime.MemStats) uint64 { return m.Mallocs },
+	"frees":          func(m *runtime.MemStats) uint64 { return m.Frees },
+	"heap_alloc":     func(m *runtime.MemStats) uint64 { return m.HeapAlloc },
+	"heap_sys":       func(m *runtime.MemStats) uint64 { return m.HeapSys },
+	"heap_idle":      func(m *runtime.MemStats) uint64 { return m.HeapIdle },
+	"heap_inuse":     func(m *runtime.MemStats) uint64 { return m.HeapInuse },
+	"heap_released":  func(m *runtime.MemStats) uint64 { return m.HeapReleased },
+	"heap_objects":   func(m *runtime.MemStats) uint64 { return m.HeapObjects },
+	"stack_inuse":    func(m *runtime.MemStats) uint64 { return m.StackInuse },
+	"stack_sys":      func(m *runtime.MemStats) uint64 { return m.StackSys },
+	"mspan_inuse":    func(m *runtime.MemStats) uint64 { return m.MSpanInuse },
+	"mspan_sys":      func(m *runtime.MemStats) uint64 { return m.MSpanSys },
+	"mcache_inuse":   func(m *runtime.MemStats) uint64 { return m.MCacheInuse },
+	"mcache_sys":     func(m *runtime.MemStats) uint64 { return m.MCacheSys },
+	"buck_hash_sys":  func(m *runtime.MemStats) uint64 { return m.BuckHashSys },
+	"gc_sys":         func(m *runtime.MemStats) uint64 { return m.GCSys },
+	"other_sys":      func(m *runtime.MemStats) uint64 { return m.OtherSys },
+	"next_gc":        func(m *runtime.MemStats) uint64 { return m.NextGC },
+	"last_gc":        func(m *runtime.MemStats) uint64 { return m.LastGC },
+	"pause_total_ns": func(m *runtime.MemStats) uint64 { return m.PauseTotalNs },
+	"num_gc":         func(m *runtime.MemStats) uint64 { return uint64(m.NumGC) },
+	"num_forced_gc":  func(m *runtime.MemStats) uint64 { return uint64(m.NumForcedGC) },
+}
+
+func itoa(v int64) string { return strconv.FormatInt(v, 10) }
+
+func contains(cols []string, col string) bool {
+	for _, c := range cols {
+		if c == col {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,82 @@
+package benchcsv_test
+
+import (
+	"os"
+	"runtime"
+	"time"
+
+	"github.com/aybabtme/benchkit"
+	"github.com/aybabtme/benchkit/benchcsv"
+)
+
+// timeResult has a slow outlier in each step, above Tukey's fences.
+func timeResult() *benchkit.TimeResult {
+	ms := time.Millisecond
+	parse := []time.Duration{ms, 11 * ms / 10, 12 * ms / 10, 10 * ms}
+	encode := []time.Duration{2 * ms, 22 * ms / 10, 24 * ms / 10, 20 * ms}
+	return &benchkit.TimeResult{
+		N:     2,
+		Names: []string{"parse", "encode"},
+		Each: []benchkit.TimeStep{
+			benchkit.NewTimeStep(parse, benchkit.TukeyFilter(1.5)),
+			benchkit.NewTimeStep(encode, benchkit.TukeyFilter(1.5)),
+		},
+	}
+}
+
+func ExampleWriteSteps() {
+	_ = benchcsv.WriteSteps(os.Stdout, timeResult(), benchcsv.Config{})
+
+	// as TSV, with only some columns
+	_ = benchcsv.WriteSteps(os.Stdout, timeResult(), benchcsv.Config{
+		Comma:   '\t',
+		Columns: []string{"step", "p50_ns", "p99_ns"},
+	})
+
+	// Output:
+	// step,count,min_ns,p50_ns,p90_ns,p99_ns,max_ns,mean_ns,sd_ns
+	// parse,4,1000000,1150000,7360000,9736000,10000000,3325000,3854461
+	// encode,4,2000000,2300000,14720000,19472000,20000000,6650000,7708923
+	// step	p50_ns	p99_ns
+	// parse	1150000	9736000
+	// encode	2300000	19472000
+}
+
+func ExampleWriteSamples() {
+	_ = benchcsv.WriteSamples(os.Stdout, timeResult(), benchcsv.Config{})
+
+	// Output:
+	// step,sample,duration_ns,outlier
+	// parse,0,1000000,
+	// parse,1,1100000,
+	// parse,2,1200000,
+	// parse,3,10000000,high
+	// encode,0,2000000,
+	// encode,1,2200000,
+	// encode,2,2400000,
+	// encode,3,20000000,high
+}
+
+func ExampleWriteMemory() {
+	res := &benchkit.MemResult{
+		N:     2,
+		Names: []string{"parse", "encode"},
+		BeforeEach: []*runtime.MemStats{
+			{TotalAlloc: 1000, Mallocs: 10, HeapAlloc: 500},
+			{TotalAlloc: 1000, Mallocs: 10, HeapAlloc: 500},
+		},
+		AfterEach: []*runtime.MemStats{
+			{TotalAlloc: 5096, Mallocs: 11, HeapAlloc: 1524},
+			{TotalAlloc: 9192, Mallocs: 12, HeapAlloc: 2548},
+		},
+	}
+
+	_ = benchcsv.WriteMemory(os.Stdout, res, benchcsv.Config{
+		Columns: []string{"step", "total_alloc_delta", "mallocs_delta", "heap_alloc_before", "heap_alloc_after"},
+	})
+
+	// Output:
+	// step,total_alloc_delta,mallocs_delta,heap_alloc_before,heap_alloc_after
+	// parse,4096,1,500,1524
+	// encode,8192,2,500,2548
+}
@@ -0,0 +1,133 @@
+package benchfmt_test
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"runtime"
+	"strings"
+	"time"
+
+	"github.com/aybabtme/benchkit"
+	"github.com/aybabtme/benchkit/benchfmt"
+)
+
+func ExampleWrite() {
+	names := []string{"parse", "encode"}
+	t := &benchkit.TimeResult{N: 2, Names: names, Each: []benchkit.TimeStep{
+		benchkit.NewTimeStep([]time.Duration{1000000, 1100000, 1200000}, benchkit.NoFilter),
+		benchkit.NewTimeStep([]time.Duration{250000, 275000, 300000}, benchkit.NoFilter),
+	}}
+	m := &benchkit.MemResult{N: 2, Names: names,
+		BeforeEach: []*runtime.MemStats{{}, {}},
+		AfterEach:  []*runtime.MemStats{{TotalAlloc: 4096, Mallocs: 3}, {TotalAlloc: 8192, Mallocs: 6}},
+	}
+
+	_ = benchfmt.Write(os.Stdout, t, m, benchfmt.Config{
+		Name:   "Tar",
+		Procs:  8,
+		Header: benchfmt.Header{Goos: "linux", Goarch: "amd64", Pkg: "example.com/tar"},
+		Units:  []benchfmt.Unit{benchfmt.Quantile(0.9)},
+	})
+
+	// Output:
+	// goos: linux
+	// goarch: amd64
+	// pkg: example.com/tar
+	// BenchmarkTar/step=parse-8	       3	1100000 ns/op	1180000 p90-ns/op	4096 B/op	3 allocs/op
+	// BenchmarkTar/step=encode-8	       3	275000 ns/op	295000 p90-ns/op	8192 B/op	6 allocs/op
+}
+
+func ExampleConfig_perSample() {
+	t := &benchkit.TimeResult{N: 1}
+	durs := []time.Duration{1200 * time.Nanosecond, 1300 * time.Nanosecond, 1250 * time.Nanosecond}
+	t.Each = append(t.Each, benchkit.NewTimeStep(durs, nil))
+
+	// feed this to benchstat to compare the distributions of the steps
+	_ = benchfmt.Write(os.Stdout, t, nil, benchfmt.Config{
+		Name:      "Parse",
+		Procs:     1,
+		PerSample: true,
+	})
+
+	// Output:
+	// BenchmarkParse/step=0	       1	1200 ns/op
+	// BenchmarkParse/step=0	       1	1250 ns/op
+	// BenchmarkParse/step=0	       1	1300 ns/op
+}
+
+func ExampleWrite_names() {
+	t := &benchkit.TimeResult{N: 1, Names: []string{"read header"}}
+	t.Each = append(t.Each, benchkit.NewTimeStep([]time.Duration{1200 * time.Nanosecond}, nil))
+
+	var buf bytes.Buffer
+	_ = benchfmt.Write(&buf, t, nil, benchfmt.Config{Name: "Tar", Procs: 1})
+	fmt.Print(buf.String())
+
+	// the line parses back to the same step
+	set, _ := benchfmt.Parse(&buf)
+	param, _ := set.Runs[0].Param("step")
+	fmt.Println(param)
+
+	// Output:
+	// BenchmarkTar/step=read_header	       1	1200 ns/op
+	// read_header
+}
+
+func ExampleParse() {
+	log := `goos: linux
+goarch: amd64
+pkg: example.com/tar
+BenchmarkTar/size=1024-8     	  500000	      2100 ns/op	    1100 B/op	       4 allocs/op
+BenchmarkTar/size=65536-8    	   20000	     61000 ns/op	   66000 B/op	       4 allocs/op
+BenchmarkTar/size=1024-8     	  500000	      2300 ns/op	    1100 B/op	       4 allocs/op
+BenchmarkTar/size=65536-8    	   20000	     59000 ns/op	   66000 B/op	       4 allocs/op
+BenchmarkTar/size=4096-8     	  200000	      5500 ns/op	    4200 B/op	       4 allocs/op
+BenchmarkTar/size=4096-8     	  200000	      5700 ns/op	    4200 B/op	       4 allocs/op
+PASS
+ok  	example.com/tar	12.345s
+`
+	set, err := benchfmt.Parse(strings.NewReader(log))
+	if err != nil {
+		panic(err)
+	}
+	fmt.Println(set.Config["pkg"], len(set.Runs))
+
+	for _, series := range set.Series("size", "ns/op") {
+		fmt.Println(series.Name, series.Procs)
+		res := series.Result
+		for i, size := range res.Names {
+			fmt.Printf("  size=%s  runs=%d  mean=%v\n", size, res.Each[i].Count(), res.Each[i].Avg)
+		}
+		// plot it with the sizes on the X axis:
+		//   benchplot.PlotTime(series.Name, "size", series.Result, false)
+	}
+
+	// Output:
+	// example.com/tar 6
+	// BenchmarkTar/size=* 8
+	//   size=1024  runs=2  mean=2.2µs
+	//   size=4096  runs=2  mean=5.6µs
+	//   size=65536  runs=2  mean=60µs
+}
+
+func ExampleSet_Series() {
+	log := `BenchmarkAdd/size=1-8    	1000000000	         0.25 ns/op
+BenchmarkAdd/size=1-8    	1000000000	         0.26 ns/op
+BenchmarkAdd/size=2-8    	1000000000	         0.5 ns/op
+`
+	set, _ := benchfmt.Parse(strings.NewReader(log))
+	for _, series := range set.Series("size", "ns/op") {
+		// the durations are scaled to keep the fractions of nanoseconds
+		res := series.Result
+		for i, size := range res.Names {
+			step := &res.Each[i]
+			fmt.Printf("size=%s  min=%g ns/op  max=%g ns/op\n", size,
+				float64(step.Quantile(0))/series.Scale, float64(step.Quantile(1))/series.Scale)
+		}
+	}
+
+	// Output:
+	// size=1  min=0.25 ns/op  max=0.26 ns/op
+	// size=2  min=0.5 ns/op  max=0.5 ns/op
+}
@@ -0,0 +1,237 @@
+package benchfmt
+
+import (
+	"bufio"
+	"io"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+
+	"github.com/aybabtme/benchkit"
+)
+
+// Set of benchmark runs read from `go test -bench` output.
+type Set struct {
+	// Config lines, such as goos or pkg. The last value of a key wins.
+	Config map[string]string
+	// Runs in the order they were read. Each repetition of a benchmark
+	// with -count is a run.
+	Runs []Run
+}
+
+// Run is a benchmark line.
+type Run struct {
+	// Name of the benchmark, such as BenchmarkFoo/size=1024, without
+	// its GOMAXPROCS suffix.
+	Name string
+	// Procs is the GOMAXPROCS suffix of the name, or 1 if it has none.
+	Procs int
+	// Pkg is the package of the benchmark, from the last pkg line.
+	Pkg        string
+	Iterations int
+	Values     []Value
+}
+
+// Value is a metric of a run, such as 1200 ns/op.
+type Value struct {
+	Value float64
+	Unit  string
+}
+
+// Value returns the value of the run in the unit, if it has one.
+func (r *Run) Value(unit string) (float64, bool) {
+	for _, v := range r.Values {
+		if v.Unit == unit {
+			return v.Value, true
+		}
+	}
+	return 0, false
+}
+
+// Param returns the value of a parameter of the name of the run, such as
+// 1024 for size in BenchmarkFoo/size=1024.
+func (r *Run) Param(key string) (string, bool) {
+	for _, part := range strings.Split(r.Name, "/")[1:] {
+		if k, v, ok := strings.Cut(part, "="); ok && k == key {
+			return v, true
+		}
+	}
+	return "", false
+}
+
+// Parse reads the config and benchmark lines of `go test -bench` output.
+// Other lines, such as the output of the tests or PASS, are skipped.
+func Parse(r io.Reader) (*Set, error) {
+	set := &Set{Config: make(map[string]string)}
+	scan := bufio.NewScanner(r)
+	scan.Buffer(nil, 1<<20)
+	for scan.Scan() {
+		line := scan.Text()
+		if run, ok := parseRun(line); ok {
+			run.Pkg = set.Config["pkg"]
+			set.Runs = append(set.Runs, run)
+			continue
+		}
+		if key, value, ok := parseConfig(line); ok {
+			set.Config[key] = value
+		}
+	}
+	return set, scan.Err()
+}
+
+// parseConfig reads a line like `goos: linux`, whose key starts with a
+// lowercase letter and has no space.
+func parseConfig(line string) (key, value string, ok bool) {
+	key, value, ok = strings.Cut(line, ":")
+	if !ok || key == "" || !unicode.IsLower(rune(key[0])) || strings.ContainsAny(key, " \t") {
+		return "", "", false
+	}
+	return key, strings.TrimSpace(value), true
+}
+
+// parseRun reads a line like `BenchmarkFoo-8  1000  1200 ns/op  64 B/op`.
+func parseRun(line string) (Run, bool) {
+	fields := strings.Fields(line)
+	if len(fields) < 4 || len(fields)%2 != 0 || !strings.HasPrefix(fields[0], "Benchmark") {
+		return Run{}, false
+	}
+	iterations, err := strconv.Atoi(fields[1])
+	if err != nil {
+		return Run{}, false
+	}
+	run := Run{Name: fields[0], Procs: 1, Iterations: iterations}
+	if i := strings.LastIndexByte(run.Name, '-'); i > 0 {
+		if procs, err := strconv.Atoi(run.Name[i+1:]); err == nil {
+			run.Name, run.Procs = run.Name[:i], procs
+		}
+	}
+	for i := 2; i < len(fields); i += 2 {
+		v, err := strconv.ParseFloat(fields[i], 64)
+		if err != nil {
+			return Run{}, false
+		}
+		run.Values = append(run.Values, Value{Value: v, Unit: fields[i+1]})
+	}
+	return run, true
+}
+
+// Series are the runs of the sub-benchmarks of a benchmark that only
+// differ by the value of a parameter, such as BenchmarkFoo/size=1024 and
+// BenchmarkFoo/size=2048 for the key size.
+type Series struct {
+	// Name of the benchmark, with a * for the value of the parameter,
+	// such as BenchmarkFoo/size=*.
+	Name  string
+	Procs int
+	Key   string
+	Unit  string
+	// Result has a step per value of the parameter, named by the value,
+	// and a sample per repetition of the sub-benchmark. All the samples
+	// are significant. Values of other units than ns/op are stored as if
+	// they were nanoseconds.
+	Result *benchkit.TimeResult
+	// Scale multiplies the values before they're stored in Result, so
+	// that their fractional digits, such as in 0.25 ns/op or 1.5 allocs/op,
+	// aren't rounded away. It's 1 if all the values are whole, otherwise
+	// divide the durations of Result by Scale to get the values back.
+	Scale float64
+}
+
+// Series groups the runs whose name has the key, reporting a value in the
+// unit, such as "ns/op". The steps are ordered by value if the values are
+// all numbers, otherwise in the order they were read.
+func (s *Set) Series(key, unit string) []Series {
+	type group struct {
+		series  Series
+		values  []string
+		samples map[string][]float64
+	}
+	var groups []*group
+	byName := make(map[string]*group)
+	for i := range s.Runs {
+		run := &s.Runs[i]
+		param, ok := run.Param(key)
+		if !ok {
+			continue
+		}
+		v, ok := run.Value(unit)
+		if !ok {
+			continue
+		}
+		name := strings.Replace(run.Name, "/"+key+"="+param, "/"+key+"=*", 1)
+		id := name + "-" + strconv.Itoa(run.Procs)
+		g, ok := byName[id]
+		if !ok {
+			g = &group{
+				series:  Series{Name: name, Procs: run.Procs, Key: key, Unit: unit},
+				samples: make(map[string][]float64),
+			}
+			byName[id] = g
+			groups = append(groups, g)
+		}
+		if _, ok := g.samples[param]; !ok {
+			g.values = append(g.values, param)
+		}
+		g.samples[param] = append(g.samples[param], v)
+	}
+
+	series := make([]Series, 0, len(groups))
+	for _, g := range groups {
+		sortValues(g.values)
+		g.series.Scale = scale(g.samples)
+		res := &benchkit.TimeResult{N: len(g.values), Names: g.values}
+		for _, value := range g.values {
+			durs := make([]time.Duration, len(g.samples[value]))
+			for i, v := range g.samples[value] {
+				durs[i] = time.Duration(math.Round(v * g.series.Scale))
+			}
+			res.Each = append(res.Each, benchkit.NewTimeStep(durs, benchkit.NoFilter))
+		}
+		g.series.Result = res
+		series = append(series, g.series)
+	}
+	return series
+}
+
+// scale finds the power of ten which makes all the samples whole, short
+// of overflowing a time.Duration.
+func scale(samples map[string][]float64) float64 {
+	var largest float64
+	for _, vs := range samples {
+		for _, v := range vs {
+			largest = math.Max(largest, math.Abs(v))
+		}
+	}
+	s := 1.0
+	for s < 1e9 && largest*s*10 < math.MaxInt64 {
+		whole := true
+		for _, vs := range samples {
+			for _, v := range vs {
+				if x := v * s; math.Abs(x-math.Round(x)) > 1e-6*math.Max(1, math.Abs(x)) {
+					whole = false
+				}
+			}
+		}
+		if whole {
+			break
+		}
+		s *= 10
+	}
+	return s
+}
+
+// sortValues sorts the values by number, if they're all numbers.
+func sortValues(values []string) {
+	nums := make(map[string]float64, len(values))
+	for _, v := range values {
+		num, err := strconv.ParseFloat(v, 64)
+		if err != nil {
+			return
+		}
+		nums[v] = num
+	}
+	sort.SliceStable(values, func(i, j int) bool { return nums[values[i]] < nums[values[j]] })
+}
@@ -0,0 +1,263 @@
+// Package benchfmt reads and writes results in the text format of
+// `go test -bench`, so that benchkit works along the standard Go tooling,
+// such as benchstat.
+package benchfmt
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"math"
+	"os"
+	"runtime"
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+
+	"github.com/aybabtme/benchkit"
+)
+
+// Header holds the configuration lines written before the benchmarks.
+// Empty lines are left out.
+type Header struct {
+	Goos   string
+	Goarch string
+	Pkg    string
+	CPU    string
+}
+
+// LocalHeader describes the machine running the benchmarks, for the
+// benchmarks of a package.
+func LocalHeader(pkg string) Header {
+	return Header{
+		Goos:   runtime.GOOS,
+		Goarch: runtime.GOARCH,
+		Pkg:    pkg,
+		CPU:    cpuName(),
+	}
+}
+
+// cpuName finds the model of the CPU, on Linux only.
+func cpuName() string {
+	data, err := os.ReadFile("/proc/cpuinfo")
+	if err != nil {
+		return ""
+	}
+	for _, line := range strings.Split(string(data), "\n") {
+		key, value, ok := strings.Cut(line, ":")
+		if ok && strings.TrimSpace(key) == "model name" {
+			return strings.TrimSpace(value)
+		}
+	}
+	return ""
+}
+
+func (h Header) write(w io.Writer) error {
+	for _, line := range [][2]string{
+		{"goos", h.Goos},
+		{"goarch", h.Goarch},
+		{"pkg", h.Pkg},
+		{"cpu", h.CPU},
+	} {
+		if line[1] == "" {
+			continue
+		}
+		if _, err := fmt.Fprintf(w, "%s: %s\n", line[0], line[1]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Unit is a custom metric of a step, reported along ns/op.
+type Unit struct {
+	// Name of the unit, such as "p90-ns/op".
+	Name  string
+	Value func(step *benchkit.TimeStep) float64
+}
+
+// Quantile reports the q-th quantile of the durations of a step, in
+// nanoseconds, such as p90-ns/op for 0.9.
+func Quantile(q float64) Unit {
+	return Unit{
+		Name: "p" + strconv.FormatFloat(q*100, 'f', -1, 64) + "-ns/op",
+		Value: func(step *benchkit.TimeStep) float64 {
+			return float64(step.Quantile(q))
+		},
+	}
+}
+
+// Config of the benchmark lines.
+type Config struct {
+	// Name of the benchmark, without its Benchmark prefix.
+	Name string
+	// Key of the sub-benchmark of each step, "step" by default, which
+	// gives BenchmarkName/step=0, or BenchmarkName/step=parse for named
+	// steps. Like for `go test`, spaces in the names become underscores.
+	Key string
+	// Procs is the GOMAXPROCS suffix of the names, runtime.GOMAXPROCS(0)
+	// by default. Like for `go test`, there's no suffix if it's 1.
+	Procs int
+	// Header lines written first, none by default.
+	Header Header
+	// PerSample writes a line per duration of a step, as if the
+	// benchmark ran many times with -count, so that benchstat sees the
+	// distribution of the step. Otherwise, a line per step reports the
+	// mean duration of the step. Steps without samples, such as the ones
+	// of a HistTime kit, always get a single line.
+	PerSample bool
+	// Units reported on the line of a step, after ns/op.
+	Units []Unit
+}
+
+// Write the steps of a run as benchmark lines. Either result may be nil.
+// With memory results, the lines report the bytes and allocations of each
+// step in B/op and allocs/op.
+func Write(w io.Writer, t *benchkit.TimeResult, m *benchkit.MemResult, c Config) error {
+	if c.Key == "" {
+		c.Key = "step"
+	}
+	if c.Procs == 0 {
+		c.Procs = runtime.GOMAXPROCS(0)
+	}
+	bw := bufio.NewWriter(w)
+	if err := c.Header.write(bw); err != nil {
+		return err
+	}
+
+	var names []string
+	n := 0
+	switch {
+	case t != nil:
+		names, n = t.Names, len(t.Each)
+	case m != nil:
+		names, n = m.Names, m.N
+	}
+	for i := 0; i < n; i++ {
+		name := c.name(names, i)
+		mem := memory(m, names, i)
+		if t == nil {
+			if err := writeLine(bw, name, 1, nil, mem); err != nil {
+				return err
+			}
+			continue
+		}
+		step := &t.Each[i]
+		units := make([]string, 0, len(c.Units))
+		for _, unit := range c.Units {
+			units = append(units, formatValue(unit.Value(step))+" "+unit.Name)
+		}
+		if !c.PerSample || len(step.Samples()) == 0 {
+			if step.Count() == 0 {
+				continue
+			}
+			ns := mean(step)
+			if err := writeLine(bw, name, step.Count(), append([]string{formatValue(ns) + " ns/op"}, units...), mem); err != nil {
+				return err
+			}
+			continue
+		}
+		for _, dur := range step.Samples() {
+			ns := formatValue(float64(dur)) + " ns/op"
+			if err := writeLine(bw, name, 1, append([]string{ns}, units...), mem); err != nil {
+				return err
+			}
+		}
+	}
+	return bw.Flush()
+}
+
+func (c Config) name(names []string, i int) string {
+	value := strconv.Itoa(i)
+	if i < len(names) {
+		value = names[i]
+	}
+	name := rewrite("Benchmark" + c.Name + "/" + c.Key + "=" + value)
+	if c.Procs != 1 {
+		name += "-" + strconv.Itoa(c.Procs)
+	}
+	return name
+}
+
+// rewrite a benchmark name like go test does, so that it stays a single
+// field: spaces become underscores, and unprintable runes are escaped.
+func rewrite(name string) string {
+	var b strings.Builder
+	for _, r := range name {
+		switch {
+		case unicode.IsSpace(r):
+			b.WriteByte('_')
+		case !strconv.IsPrint(r):
+			s := strconv.QuoteRune(r)
+			b.WriteString(s[1 : len(s)-1])
+		default:
+			b.WriteRune(r)
+		}
+	}
+	return b.String()
+}
+
+// memory reports the bytes and allocations of a step, matched by name if
+// the steps are named.
+func memory(m *benchkit.MemResult, names []string, i int) []string {
+	if m == nil {
+		return nil
+	}
+	j := i
+	if len(m.Names) != 0 && i < len(names) {
+		j = m.Index(names[i])
+	}
+	if j < 0 || j >= len(m.BeforeEach) || j >= len(m.AfterEach) {
+		return nil
+	}
+	before, after := m.BeforeEach[j], m.AfterEach[j]
+	return []string{
+		strconv.FormatUint(after.TotalAlloc-before.TotalAlloc, 10) + " B/op",
+		strconv.FormatUint(after.Mallocs-before.Mallocs, 10) + " allocs/op",
+	}
+}
+
+// mean is the mean of all the durations of the step, like the ns/op of
+// `go test`, rather than the one of its significant durations.
+func mean(step *benchkit.TimeStep) float64 {
+	samples := step.Samples()
+	if len(samples) == 0 {
+		return float64(step.Avg)
+	}
+	var sum time.Duration
+	for _, dur := range samples {
+		sum += dur
+	}
+	return float64(sum) / float64(len(samples))
+}
+
+func writeLine(w io.Writer, name string, iterations int, units, mem []string) error {
+	line := fmt.Sprintf("%s\t%8d", name, iterations)
+	for _, unit := range append(units, mem...) {
+		line += "\t" + unit
+	}
+	_, err := fmt.Fprintln(w, line)
+	return err
+}
+
+// formatValue prints a value with a few significant digits, like the
+// metrics of `go test`.
+func formatValue(v float64) string {
+	var prec int
+	switch av := math.Abs(v); {
+	case av == 0 || av >= 999.95:
+		prec = 0
+	case av >= 99.995:
+		prec = 1
+	case av >= 9.9995:
+		prec = 2
+	case av >= 0.99995:
+		prec = 3
+	case av >= 0.099995:
+		prec = 4
+	default:
+		prec = 5
+	}
+	return strconv.FormatFloat(v, 'f', prec, 64)
+}
@@ -0,0 +1,310 @@
+package benchplot
+
+import (
+	"image/color"
+	"math"
+	"sort"
+	"time"
+
+	"github.com/aybabtme/benchkit"
+	"gonum.org/v1/plot"
+	"gonum.org/v1/plot/plotter"
+	"gonum.org/v1/plot/vg"
+	"gonum.org/v1/plot/vg/draw"
+)
+
+// BoxStyle of the boxes of PlotBoxes. The zero value is ready to use.
+type BoxStyle struct {
+	// Whiskers reach the lowest and highest durations kept by the filter,
+	// and the durations beyond are drawn as outliers. By default, it's the
+	// TukeyFilter(1.5) of the classic box plot. With NoFilter, whiskers
+	// reach the extremes and there are no outliers.
+	Whiskers benchkit.Filter
+	// Outliers is the glyph of the outliers, small rings by default.
+	Outliers draw.GlyphStyle
+	// HideOutliers leaves the outliers out of the plot.
+	HideOutliers bool
+	// Width of the boxes, which share the width of the plot by default.
+	Width vg.Length
+	// FillColor of the boxes, none by default.
+	FillColor color.Color
+}
+
+// PlotBoxes draws a box and whiskers for the durations of each step. The
+// box goes from the first to the third quartile, with a line at the
+// median. Steps of a HistTime kit have no samples, so their whiskers reach
+// their extremes.
+func PlotBoxes(title, xLabel string, results *benchkit.TimeResult, logscale bool, style BoxStyle) (*plot.Plot, error) {
+	p := newTimePlot(title, xLabel, results, logscale)
+
+	if style.Whiskers == nil {
+		style.Whiskers = benchkit.TukeyFilter(1.5)
+	}
+	if style.Outliers.Shape == nil {
+		style.Outliers = draw.GlyphStyle{
+			Color:  color.RGBA{166, 189, 219, 255},
+			Radius: vg.Points(1.5),
+			Shape:  draw.RingGlyph{},
+		}
+	}
+	if style.Width == 0 {
+		style.Width = stepWidth(len(results.Each))
+	}
+
+	for i := range results.Each {
+		step := &results.Each[i]
+		if step.Count() == 0 {
+			continue
+		}
+		samples := step.Samples()
+		q := make([]float64, 5)
+		for j, dur := range step.Quantiles(0, 0.25, 0.5, 0.75, 1) {
+			q[j] = float64(dur)
+		}
+		values := plotter.Values(q)
+		if len(samples) != 0 {
+			values = make(plotter.Values, len(samples))
+			for j, dur := range samples {
+				values[j] = float64(dur)
+			}
+		}
+		box, err := plotter.NewBoxPlot(style.Width, float64(i), values)
+		if err != nil {
+			return nil, err
+		}
+		box.Quartile1, box.Median, box.Quartile3 = q[1], q[2], q[3]
+		box.AdjLow, box.AdjHigh, box.Outside = q[0], q[4], nil
+		if len(samples) != 0 {
+			box.AdjLow, box.AdjHigh, box.Outside = whiskers(samples, style.Whiskers)
+		}
+		// whiskers never go inside the box
+		box.AdjLow = math.Min(box.AdjLow, box.Quartile1)
+		box.AdjHigh = math.Max(box.AdjHigh, box.Quartile3)
+		if style.HideOutliers {
+			box.Outside = nil
+		}
+		box.GlyphStyle = style.Outliers
+		box.FillColor = style.FillColor
+		p.Add(box)
+	}
+
+	return p, nil
+}
+
+// whiskers finds the ends of the whiskers of sorted durations, and the
+// indices of the outliers.
+func whiskers(sorted []time.Duration, filter benchkit.Filter) (low, high float64, outside []int) {
+	lo, hi, _, _ := filter.Window(sorted)
+	if lo < 0 {
+		lo = 0
+	}
+	if hi > len(sorted) {
+		hi = len(sorted)
+	}
+	if lo >= hi {
+		// nothing kept, so the whiskers stay within the box
+		return math.Inf(1), math.Inf(-1), nil
+	}
+	for i := 0; i < lo; i++ {
+		outside = append(outside, i)
+	}
+	for i := hi; i < len(sorted); i++ {
+		outside = append(outside, i)
+	}
+	return float64(sorted[lo]), float64(sorted[hi-1]), outside
+}
+
+// ViolinStyle of the violins of PlotViolins. The zero value is ready to
+// use.
+type ViolinStyle struct {
+	// Width of the violins at their widest, which share the width of the
+	// plot by default.
+	Width vg.Length
+	// Points at which the density of a step is estimated, 50 by default.
+	Points int
+	// FillColor of the violins, light blue by default.
+	FillColor color.Color
+	// Line is the outline of the violins, and the color of the bar from
+	// their first to their third quartile.
+	Line draw.LineStyle
+}
+
+// PlotViolins draws a violin for the durations of each step: its width is
+// the density of the durations, estimated with a Gaussian kernel, and a bar
+// goes from the first to the third quartile, with a mark at the median. On
+// a log scale, the density is estimated over the log of the durations.
+//
+// The densities are estimated from at most 2000 durations of each step,
+// evenly spread over their quantiles. Steps of a HistTime kit have no
+// samples, so their density is estimated from their quantiles.
+func PlotViolins(title, xLabel string, results *benchkit.TimeResult, logscale bool, style ViolinStyle) (*plot.Plot, error) {
+	p := newTimePlot(title, xLabel, results, logscale)
+
+	if style.Width == 0 {
+		style.Width = stepWidth(len(results.Each))
+	}
+	if style.Points < 2 {
+		style.Points = 50
+	}
+	if style.FillColor == nil {
+		style.FillColor = color.RGBA{166, 189, 219, 255}
+	}
+	if style.Line.Color == nil {
+		style.Line = draw.LineStyle{
+			Color: color.RGBA{43, 140, 190, 255},
+			Width: vg.Points(0.5),
+		}
+	}
+
+	for i := range results.Each {
+		step := &results.Each[i]
+		if step.Count() == 0 {
+			continue
+		}
+		q := step.Quantiles(0.25, 0.5, 0.75)
+		v := &violin{
+			loc:    float64(i),
+			style:  style,
+			q1:     float64(q[0]),
+			median: float64(q[1]),
+			q3:     float64(q[2]),
+		}
+		v.ys, v.density = density(spread(step, 2000), style.Points, logscale)
+		p.Add(v)
+	}
+
+	return p, nil
+}
+
+// spread picks at most n durations of the step, evenly spread over their
+// quantiles, in increasing order.
+func spread(step *benchkit.TimeStep, n int) []float64 {
+	samples := step.Samples()
+	if len(samples) == 0 || len(samples) > n {
+		out := make([]float64, n)
+		for i := range out {
+			out[i] = float64(step.Quantile(float64(i) / float64(n-1)))
+		}
+		return out
+	}
+	out := make([]float64, len(samples))
+	for i, dur := range samples {
+		out[i] = float64(dur)
+	}
+	return out
+}
+
+// density estimates the density of sorted values at points spanning them,
+// with a Gaussian kernel and Silverman's rule for the bandwidth. The
+// densities are scaled so that the highest is 1.
+func density(sorted []float64, points int, logscale bool) (ys, dens []float64) {
+	to, from := func(v float64) float64 { return v }, func(v float64) float64 { return v }
+	if logscale {
+		to = func(v float64) float64 { return math.Log(math.Max(v, 1)) }
+		from = math.Exp
+	}
+	zs := make([]float64, len(sorted))
+	var mean float64
+	for i, v := range sorted {
+		zs[i] = to(v)
+		mean += zs[i]
+	}
+	sort.Float64s(zs)
+	n := float64(len(zs))
+	mean /= n
+	var variance float64
+	for _, z := range zs {
+		variance += (z - mean) * (z - mean)
+	}
+	sd := math.Sqrt(variance / n)
+	iqr := zs[int(0.75*(n-1))] - zs[int(0.25*(n-1))]
+	spreadZ := sd
+	if iqr > 0 && iqr/1.34 < sd {
+		spreadZ = iqr / 1.34
+	}
+	h := 0.9 * spreadZ * math.Pow(n, -0.2)
+	lo, hi := zs[0], zs[len(zs)-1]
+	if h == 0 {
+		// all the values are the same
+		return []float64{from(lo)}, []float64{1}
+	}
+
+	ys = make([]float64, points)
+	dens = make([]float64, points)
+	var highest float64
+	for i := range ys {
+		z := lo + (hi-lo)*float64(i)/float64(points-1)
+		var sum float64
+		for _, zi := range zs {
+			u := (z - zi) / h
+			sum += math.Exp(-0.5 * u * u)
+		}
+		ys[i], dens[i] = from(z), sum
+		highest = math.Max(highest, sum)
+	}
+	for i := range dens {
+		dens[i] /= highest
+	}
+	return ys, dens
+}
+
+// violin plots the density of the durations of a step.
+type violin struct {
+	loc     float64
+	style   ViolinStyle
+	ys      []float64
+	density []float64
+
+	q1, median, q3 float64
+}
+
+// Plot implements the plot.Plotter interface.
+func (v *violin) Plot(c draw.Canvas, plt *plot.Plot) {
+	trX, trY := plt.Transforms(&c)
+	x := trX(v.loc)
+	if !c.ContainsX(x) {
+		return
+	}
+
+	half := v.style.Width / 2
+	outline := make([]vg.Point, 0, 2*len(v.ys)+1)
+	for i, y := range v.ys {
+		outline = append(outline, vg.Point{X: x - vg.Length(v.density[i])*half, Y: trY(y)})
+	}
+	for i := len(v.ys) - 1; i >= 0; i-- {
+		outline = append(outline, vg.Point{X: x + vg.Length(v.density[i])*half, Y: trY(v.ys[i])})
+	}
+	outline = append(outline, outline[0])
+	c.FillPolygon(v.style.FillColor, c.ClipPolygonY(outline))
+	c.StrokeLines(v.style.Line, c.ClipLinesY(outline)...)
+
+	bar := draw.LineStyle{Color: v.style.Line.Color, Width: vg.Points(3)}
+	c.StrokeLines(bar, c.ClipLinesY([]vg.Point{{X: x, Y: trY(v.q1)}, {X: x, Y: trY(v.q3)}})...)
+	mark := draw.LineStyle{Color: color.White, Width: vg.Points(1.5)}
+	med := trY(v.median)
+	c.StrokeLines(mark, c.ClipLinesY([]vg.Point{{X: x - vg.Points(1.5), Y: med}, {X: x + vg.Points(1.5), Y: med}})...)
+}
+
+// DataRange implements the plot.DataRanger interface.
+func (v *violin) DataRange() (xmin, xmax, ymin, ymax float64) {
+	return v.loc, v.loc, v.ys[0], v.ys[len(v.ys)-1]
+}
+
+// GlyphBoxes implements the plot.GlyphBoxer interface, to leave room for
+// the width of the violin.
+func (v *violin) GlyphBoxes(plt *plot.Plot) []plot.GlyphBox {
+	half := v.style.Width / 2
+	return []plot.GlyphBox{{
+		X: plt.X.Norm(v.loc),
+		Y: plt.Y.Norm(v.median),
+		Rectangle: vg.Rectangle{
+			Min: vg.Point{X: -half},
+			Max: vg.Point{X: half},
+		},
+	}}
+}
+
+// stepWidth shares the width of a plot between n steps.
+func stepWidth(n int) vg.Length {
+	return vg.Points(300 / float64(n+1))
+}
@@ -4,7 +4,9 @@ import (
 	"archive/tar"
 	"bytes"
 	"fmt"
+	"image/color"
 	"os"
+	"path/filepath"
 	"runtime"
 	"strconv"
 	"time"
@@ -54,6 +56,58 @@ func ExamplePlotTime() {
 	//
 }
 
+func ExamplePlotTime_series() {
+	results := &benchkit.TimeResult{N: 10}
+	for i := 1; i <= 10; i++ {
+		var durs []time.Duration
+		for j := 0; j < 100; j++ {
+			durs = append(durs, time.Duration(i*1000+j*j))
+		}
+		results.Each = append(results.Each, benchkit.NewTimeStep(durs, nil))
+	}
+
+	p, err := PlotTime("Series", "Steps", results, false,
+		P50, P90, P99, Mean, PlusSD, MinusSD,
+		Quantile(0.999, 0.3, color.Black),
+	)
+	if err != nil {
+		panic(err)
+	}
+	_ = p.Save(960, 720, filepath.Join(os.TempDir(), "timeplot_series.svg"))
+
+	// Output:
+	//
+}
+
+func ExamplePlotBoxes() {
+	results := &benchkit.TimeResult{N: 10}
+	for i := 1; i <= 10; i++ {
+		var durs []time.Duration
+		for j := 0; j < 1000; j++ {
+			durs = append(durs, time.Duration(i*1000+j*j/100))
+		}
+		results.Each = append(results.Each, benchkit.NewTimeStep(durs, nil))
+	}
+
+	// whiskers from p1 to p99, the other durations are outliers
+	p, err := PlotBoxes("Boxes", "Steps", results, false, BoxStyle{
+		Whiskers: benchkit.QuantileFilter(0.01, 0.99),
+	})
+	if err != nil {
+		panic(err)
+	}
+	_ = p.Save(960, 720, filepath.Join(os.TempDir(), "timeplot_boxes.svg"))
+
+	p, err = PlotViolins("Violins", "Steps", results, false, ViolinStyle{})
+	if err != nil {
+		panic(err)
+	}
+	_ = p.Save(960, 720, filepath.Join(os.TempDir(), "timeplot_violins.svg"))
+
+	// Output:
+	//
+}
+
 func ExamplePlotTime_bench() {
 	n := 100
 	times := 100
@@ -89,6 +143,52 @@ func ExamplePlotTime_bench() {
 	//
 }
 
+func ExamplePlotTime_run() {
+	n := 100
+	times := 100
+	size := int(1e6)
+	buf := bytes.NewBuffer(nil)
+	var files []TarFile
+
+	timekit, results := benchkit.Time(n, times)
+	results, err := benchkit.Run(timekit, results,
+		func() error {
+			files = GenTarFiles(n, size)
+			return nil
+		},
+		func(each benchkit.BenchEach) error {
+			for repeat := 0; repeat < times; repeat++ {
+				buf.Reset()
+				tarw := tar.NewWriter(buf)
+				for j, file := range files {
+					each.Before(j)
+					_ = tarw.WriteHeader(file.TarHeader())
+					_, _ = tarw.Write(file.Data())
+					each.After(j)
+				}
+				if err := tarw.Close(); err != nil {
+					return err
+				}
+			}
+			return nil
+		},
+	)
+	if err != nil {
+		panic(err)
+	}
+
+	p, _ := PlotTime(
+		fmt.Sprintf("archive/tar time usage for %d files, %s each, over %d measurements", n, humanize.Bytes(uint64(size)), times),
+		"Files in archive",
+		results,
+		true,
+	)
+	_ = p.Save(960, 720, filepath.Join(os.TempDir(), "tar_timeplot.png"))
+
+	// Output:
+	//
+}
+
 func ExamplePlotMemory() {
 	n := 100
 	size := int(1e6)
@@ -159,6 +259,45 @@ func ExamplePlotMemory_bench() {
 	//
 }
 
+func ExamplePlotMemory_run() {
+	n := 100
+	size := int(1e6)
+	buf := bytes.NewBuffer(nil)
+	var files []TarFile
+
+	memkit, results := benchkit.Memory(n)
+	results, err := benchkit.Run(memkit, results,
+		func() error {
+			files = GenTarFiles(n, size)
+			return nil
+		},
+		func(each benchkit.BenchEach) error {
+			tarw := tar.NewWriter(buf)
+			for j, file := range files {
+				each.Before(j)
+				_ = tarw.WriteHeader(file.TarHeader())
+				_, _ = tarw.Write(file.Data())
+				each.After(j)
+			}
+			return tarw.Close()
+		},
+	)
+	if err != nil {
+		panic(err)
+	}
+
+	p, _ := PlotMemory(
+		fmt.Sprintf("archive/tar memory usage for %d files, %s each", n, humanize.Bytes(uint64(size))),
+		"Files in archive",
+		results,
+		false,
+	)
+	_ = p.Save(960, 720, filepath.Join(os.TempDir(), "tar_memplot.png"))
+
+	// Output:
+	//
+}
+
 func effectMem(mem *runtime.MemStats) string {
 	effectMem := mem.Sys - mem.HeapReleased
 	return humanize.Bytes(effectMem)
@@ -205,3 +344,39 @@ func (t *TarFile) TarHeader() *tar.Header {
 		Gid:        os.Getgid(),
 	}
 }
+
+func ExamplePlotSpans() {
+	n, times := 10, 100
+
+	spankit, results := benchkit.Spans(n, times)
+	spankit.Setup()
+	spankit.Starting()
+	each := spankit.Spans()
+	for repeat := 0; repeat < times; repeat++ {
+		for i := 0; i < n; i++ {
+			each.Before(i)
+			each.Enter("read")
+			_ = bytes.Repeat([]byte{'r'}, 1000*(i+1))
+			each.Exit()
+			// stacked apart from the time spent outside of the spans
+			each.Enter("other")
+			_ = bytes.Repeat([]byte{'o'}, 1000*(n-i))
+			each.Exit()
+			each.After(i)
+		}
+	}
+	spankit.Teardown()
+
+	p, err := PlotSpans("Spans", "Steps", results)
+	if err != nil {
+		panic(err)
+	}
+	_ = p.Save(960, 720, filepath.Join(os.TempDir(), "spanplot.svg"))
+
+	// without any step, the chart is empty
+	_, err = PlotSpans("Spans", "Steps", &benchkit.SpanResult{})
+	fmt.Println(err)
+
+	// Output:
+	// <nil>
+}
@@ -2,6 +2,7 @@ package benchplot
 
 import (
 	"image/color"
+	"math"
 	"runtime"
 
 	"github.com/aybabtme/benchkit"
@@ -76,13 +77,16 @@ func PlotMemory(title, xLabel string, results *benchkit.MemResult, logscale bool
 	if logscale {
 		p.Y.Label.Text = "Memory usage (log10)"
 
-		p.Y.Scale = plot.LogScale{}
-		p.Y.Tick.Marker = readableBytes(plot.LogTicks{})
+		p.Y.Scale = logScale{}
+		p.Y.Tick.Marker = readableBytes(logTicks())
 	} else {
 		p.Y.Label.Text = "Memory usage"
 		p.Y.Tick.Marker = readableBytes(p.Y.Tick.Marker)
 	}
 	p.X.Label.Text = xLabel
+	if len(results.Names) != 0 {
+		p.X.Tick.Marker = namedSteps(results.Names)
+	}
 
 	p.Add(plotter.NewGrid())
 
@@ -113,6 +117,33 @@ type tickerFunc func(min, max float64) []plot.Tick
 
 func (tkfn tickerFunc) Ticks(min, max float64) []plot.Tick { return tkfn(min, max) }
 
+// logScale is a log scale which clamps the values it can't show, zero or
+// below, to 1. Empty steps are then drawn at the bottom of the plot,
+// rather than panic.
+type logScale struct{}
+
+func (logScale) Normalize(min, max, x float64) float64 {
+	min, max = clampLog(min, max)
+	return plot.LogScale{}.Normalize(min, max, math.Max(x, 1))
+}
+
+// logTicks are the ticks of a logScale.
+func logTicks() plot.Ticker {
+	return tickerFunc(func(min, max float64) []plot.Tick {
+		min, max = clampLog(min, max)
+		return plot.LogTicks{}.Ticks(min, max)
+	})
+}
+
+// clampLog clamps the range of an axis to the values a log scale can show.
+func clampLog(min, max float64) (float64, float64) {
+	min = math.Max(min, 1)
+	if max <= min {
+		max = 10 * min
+	}
+	return min, max
+}
+
 func readableBytes(marker plot.Ticker) plot.Ticker {
 	return tickerFunc(func(min, max float64) []plot.Tick {
 		var out []plot.Tick
@@ -123,3 +154,16 @@ func readableBytes(marker plot.Ticker) plot.Ticker {
 		return out
 	})
 }
+
+// namedSteps labels the steps on the X axis with their names.
+func namedSteps(names []string) plot.Ticker {
+	return tickerFunc(func(min, max float64) []plot.Tick {
+		var out []plot.Tick
+		for i, name := range names {
+			if x := float64(i); x >= min && x <= max {
+				out = append(out, plot.Tick{Value: x, Label: name})
+			}
+		}
+		return out
+	})
+}
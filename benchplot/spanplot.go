@@ -0,0 +1,93 @@
+package benchplot
+
+import (
+	"time"
+
+	"github.com/aybabtme/benchkit"
+	"gonum.org/v1/plot"
+	"gonum.org/v1/plot/plotter"
+	"gonum.org/v1/plot/plotutil"
+	"gonum.org/v1/plot/vg"
+)
+
+// PlotSpans will create a stacked bar chart of the spans of each step. Each
+// bar is the time spent in a top level span of the step, on average per run
+// of the step. The time of the step spent outside of its spans is stacked on
+// top, as "other".
+func PlotSpans(title, xLabel string, results *benchkit.SpanResult) (*plot.Plot, error) {
+
+	p := plot.New()
+
+	p.Title.Text = title
+	p.Y.Label.Text = "Duration"
+	p.Y.Tick.Marker = readableDuration(p.Y.Tick.Marker)
+	p.X.Label.Text = xLabel
+
+	p.Add(plotter.NewGrid())
+	if len(results.Each) == 0 {
+		return p, nil
+	}
+
+	// the spans of each step can differ, so stack all the names ever seen
+	var names []string
+	seen := make(map[string]bool)
+	for _, step := range results.Each {
+		for _, span := range step.Children {
+			if !seen[span.Name] {
+				seen[span.Name] = true
+				names = append(names, span.Name)
+			}
+		}
+	}
+
+	width := vg.Points(300 / float64(len(results.Each)+1))
+	var below *plotter.BarChart
+	for i := 0; i <= len(names); i++ {
+		// the self time comes last, keyed by its position rather than by
+		// a name, which a span could use too
+		name, total := "other", (*benchkit.Span).Self
+		if i < len(names) {
+			name, total = names[i], spanTotal(names[i])
+		}
+		bars, err := plotter.NewBarChart(mapSpans(total, results.Each), width)
+		if err != nil {
+			return nil, err
+		}
+		bars.LineStyle.Width = 0
+		bars.Color = plotutil.Color(i)
+		if below != nil {
+			bars.StackOn(below)
+		}
+		below = bars
+		p.Add(bars)
+		p.Legend.Add(name, bars)
+	}
+
+	return p, nil
+}
+
+// mapSpans gives the average of the total time per run of each step.
+func mapSpans(total func(step *benchkit.Span) time.Duration, steps []benchkit.Span) plotter.Values {
+	values := make(plotter.Values, len(steps))
+	for i := range steps {
+		runs := steps[i].Time.Count()
+		if runs == 0 {
+			continue
+		}
+		values[i] = float64(total(&steps[i])) / float64(runs)
+	}
+	return values
+}
+
+// spanTotal is the time spent in the named top level span of a step.
+func spanTotal(name string) func(step *benchkit.Span) time.Duration {
+	return func(step *benchkit.Span) time.Duration {
+		var sum time.Duration
+		for i := range step.Children {
+			if step.Children[i].Name == name {
+				sum += step.Children[i].Total()
+			}
+		}
+		return sum
+	}
+}
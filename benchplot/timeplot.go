@@ -2,6 +2,7 @@ package benchplot
 
 import (
 	"image/color"
+	"strconv"
 	"time"
 
 	"github.com/aybabtme/benchkit"
@@ -11,56 +12,90 @@ import (
 	"gonum.org/v1/plot/vg/draw"
 )
 
-var timelines = []struct {
-	Name   string
-	Filter func(t benchkit.TimeStep) float64
-	Width  float64
-	Color  color.Color
-}{
-	{
-		Name:   "p50",
-		Filter: func(t benchkit.TimeStep) float64 { return float64(t.P(50)) },
-		Width:  0.5,
-		Color:  color.RGBA{43, 140, 190, 255},
-	},
-	// {
-	// 	Name:   "p90",
-	// 	Filter: func(t benchkit.TimeStep) float64 { return float64(t.P(90)) },
-	// 	Width:  1,
-	// 	Color:  color.RGBA{252, 141, 89, 255},
-	// },
-	// {
-	// 	Name:   "p99",
-	// 	Filter: func(t benchkit.TimeStep) float64 { return float64(t.P(99)) },
-	// 	Width:  0.3,
-	// 	Color:  color.RGBA{215, 48, 39, 255},
-	// },
-	// {
-	// 	Name:   "average",
-	// 	Filter: func(t benchkit.TimeStep) float64 { return float64(t.Avg) },
-	// 	Width:  1,
-	// 	Color:  color.RGBA{254, 224, 144, 255},
-	// },
+// TimeSeries is a line drawn by PlotTime through a statistic of each step.
+type TimeSeries struct {
+	// Name of the series in the legend.
+	Name string
+	// Value of the series at a step, in nanoseconds.
+	Value func(step benchkit.TimeStep) float64
+	// Width of the line, in points.
+	Width float64
+	Color color.Color
+	// Dashes of the line, solid if empty.
+	Dashes []vg.Length
 }
 
-// PlotTime does stuff.
-func PlotTime(title, xLabel string, results *benchkit.TimeResult, logscale bool) (*plot.Plot, error) {
+// Ready-made series. They're about all the durations of each step,
+// outliers included, like its Summary.
+var (
+	P50 = Quantile(0.5, 0.5, color.RGBA{43, 140, 190, 255})
+	P90 = Quantile(0.9, 1, color.RGBA{252, 141, 89, 255})
+	P99 = Quantile(0.99, 0.3, color.RGBA{215, 48, 39, 255})
 
-	p := plot.New()
+	Mean = TimeSeries{
+		Name:  "average",
+		Value: func(t benchkit.TimeStep) float64 { return float64(t.Summary().Mean) },
+		Width: 1,
+		Color: color.RGBA{254, 224, 144, 255},
+	}
+	Min = TimeSeries{
+		Name:   "min",
+		Value:  func(t benchkit.TimeStep) float64 { return float64(t.Quantile(0)) },
+		Width:  0.5,
+		Color:  color.RGBA{116, 173, 209, 255},
+		Dashes: []vg.Length{vg.Points(2), vg.Points(2)},
+	}
+	Max = TimeSeries{
+		Name:   "max",
+		Value:  func(t benchkit.TimeStep) float64 { return float64(t.Quantile(1)) },
+		Width:  0.5,
+		Color:  color.RGBA{69, 117, 180, 255},
+		Dashes: []vg.Length{vg.Points(2), vg.Points(2)},
+	}
+	// PlusSD and MinusSD are one standard deviation above and below the
+	// average. MinusSD can drop to zero or below, which a log scale draws
+	// at its bottom.
+	PlusSD = TimeSeries{
+		Name: "+σ",
+		Value: func(t benchkit.TimeStep) float64 {
+			sum := t.Summary()
+			return float64(sum.Mean + sum.SD)
+		},
+		Width:  0.5,
+		Color:  color.RGBA{254, 224, 144, 255},
+		Dashes: []vg.Length{vg.Points(4), vg.Points(2)},
+	}
+	MinusSD = TimeSeries{
+		Name: "-σ",
+		Value: func(t benchkit.TimeStep) float64 {
+			sum := t.Summary()
+			return float64(sum.Mean - sum.SD)
+		},
+		Width:  0.5,
+		Color:  color.RGBA{254, 224, 144, 255},
+		Dashes: []vg.Length{vg.Points(4), vg.Points(2)},
+	}
+)
 
-	p.Title.Text = title
-	if logscale {
-		p.Y.Label.Text = "Duration (log10)"
-		p.Y.Scale = plot.LogScale{}
-		p.Y.Tick.Marker = readableDuration(plot.LogTicks{})
-	} else {
-		p.Y.Label.Text = "Duration"
-		p.Y.Tick.Marker = readableDuration(p.Y.Tick.Marker)
+// DefaultTimeSeries are drawn by PlotTime when it's given no series.
+var DefaultTimeSeries = []TimeSeries{P50}
+
+// Quantile is the series of the q-th quantile of the steps, with
+// 0 <= q <= 1, named like p90 for 0.9.
+func Quantile(q, width float64, c color.Color) TimeSeries {
+	return TimeSeries{
+		Name:  "p" + strconv.FormatFloat(q*100, 'f', -1, 64),
+		Value: func(t benchkit.TimeStep) float64 { return float64(t.Quantile(q)) },
+		Width: width,
+		Color: c,
 	}
+}
 
-	p.X.Label.Text = xLabel
+// PlotTime plots the durations of the steps, with a line for each of the
+// series, or for the DefaultTimeSeries if none is given.
+func PlotTime(title, xLabel string, results *benchkit.TimeResult, logscale bool, series ...TimeSeries) (*plot.Plot, error) {
 
-	p.Add(plotter.NewGrid())
+	p := newTimePlot(title, xLabel, results, logscale)
 
 	scatter, err := plotter.NewScatter(func() plotter.XYs {
 		var xys plotter.XYs
@@ -81,13 +116,17 @@ func PlotTime(title, xLabel string, results *benchkit.TimeResult, logscale bool)
 	scatter.GlyphStyle.Radius = vg.Points(1)
 	p.Add(scatter)
 
-	for _, data := range timelines {
-		line, err := plotter.NewLine(mapSteps(data.Filter, results.Each))
+	if len(series) == 0 {
+		series = DefaultTimeSeries
+	}
+	for _, data := range series {
+		line, err := plotter.NewLine(mapSteps(data.Value, results.Each))
 		if err != nil {
 			return nil, err
 		}
 		line.Width = vg.Points(data.Width)
 		line.Color = data.Color
+		line.Dashes = data.Dashes
 		p.Add(line)
 		p.Legend.Add(data.Name, line)
 	}
@@ -95,6 +134,29 @@ func PlotTime(title, xLabel string, results *benchkit.TimeResult, logscale bool)
 	return p, nil
 }
 
+// newTimePlot sets up a plot of the durations of each step.
+func newTimePlot(title, xLabel string, results *benchkit.TimeResult, logscale bool) *plot.Plot {
+	p := plot.New()
+
+	p.Title.Text = title
+	if logscale {
+		p.Y.Label.Text = "Duration (log10)"
+		p.Y.Scale = logScale{}
+		p.Y.Tick.Marker = readableDuration(logTicks())
+	} else {
+		p.Y.Label.Text = "Duration"
+		p.Y.Tick.Marker = readableDuration(p.Y.Tick.Marker)
+	}
+
+	p.X.Label.Text = xLabel
+	if len(results.Names) != 0 {
+		p.X.Tick.Marker = namedSteps(results.Names)
+	}
+
+	p.Add(plotter.NewGrid())
+	return p
+}
+
 func mapSteps(f func(step benchkit.TimeStep) float64, steps []benchkit.TimeStep) plotter.XYs {
 	xys := make(plotter.XYs, len(steps))
 	for i, step := range steps {
@@ -0,0 +1,43 @@
+package benchreport_test
+
+import (
+	"bytes"
+	"fmt"
+	"runtime"
+	"strings"
+	"time"
+
+	"github.com/aybabtme/benchkit"
+	"github.com/aybabtme/benchkit/benchreport"
+)
+
+func ExampleReport() {
+	names := []string{"parse", "encode"}
+	t := &benchkit.TimeResult{N: 2, Names: names, Each: []benchkit.TimeStep{
+		benchkit.NewTimeStep([]time.Duration{time.Millisecond, 1100 * time.Microsecond, 1200 * time.Microsecond}, nil),
+		benchkit.NewTimeStep([]time.Duration{2 * time.Millisecond}, nil),
+	}}
+	m := &benchkit.MemResult{N: 2, Names: names,
+		BeforeEach: []*runtime.MemStats{{}, {}},
+		AfterEach:  []*runtime.MemStats{{TotalAlloc: 1 << 19, Mallocs: 5}, {TotalAlloc: 1 << 20, Mallocs: 10}},
+	}
+
+	report := benchreport.New("archive/tar")
+	report.AddTime("Time per file", "Files", t)
+	report.AddMemory("Memory per file", "Files", m)
+
+	buf := bytes.NewBuffer(nil)
+	// or report.Save("report.html")
+	if err := report.Write(buf); err != nil {
+		panic(err)
+	}
+	html := buf.String()
+	fmt.Println(strings.Count(html, "<svg"), "charts")
+	fmt.Println(strings.Contains(html, "<td>parse</td><td>3</td><td>1ms</td><td>1.1ms</td>"))
+	fmt.Println(strings.Contains(html, "<td>encode</td><td>1.0 MB</td><td>10</td>"))
+
+	// Output:
+	// 2 charts
+	// true
+	// true
+}
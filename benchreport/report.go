@@ -0,0 +1,227 @@
+// Package benchreport writes the results of benchkit as a self-contained
+// HTML page, with charts, summary tables, the environment of the run and
+// the raw data. The page loads no external asset, so it can be attached to
+// a CI run or sent by email.
+package benchreport
+
+import (
+	"bytes"
+	"fmt"
+	"html/template"
+	"io"
+	"os"
+	"runtime"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/aybabtme/benchkit"
+	"github.com/aybabtme/benchkit/benchcsv"
+	"github.com/aybabtme/benchkit/benchfmt"
+	"github.com/aybabtme/benchkit/benchplot"
+	"github.com/dustin/go-humanize"
+	"gonum.org/v1/plot"
+	"gonum.org/v1/plot/vg"
+)
+
+// Entry of the environment of a report.
+type Entry struct {
+	Key   string
+	Value string
+}
+
+// Report of the results of benchmarks.
+type Report struct {
+	Title string
+	// Env describes the environment of the benchmarks.
+	Env []Entry
+	// LogScale plots durations and memory on a log scale.
+	LogScale bool
+
+	sections []section
+}
+
+type section struct {
+	name   string
+	xLabel string
+	time   *benchkit.TimeResult
+	memory *benchkit.MemResult
+}
+
+// New report, describing the environment of the running program.
+func New(title string) *Report {
+	return &Report{Title: title, Env: LocalEnv()}
+}
+
+// LocalEnv describes the environment of the running program.
+func LocalEnv() []Entry {
+	hostname, _ := os.Hostname()
+	env := []Entry{
+		{"date", time.Now().Format(time.RFC3339)},
+		{"go", runtime.Version()},
+		{"goos", runtime.GOOS},
+		{"goarch", runtime.GOARCH},
+		{"cpu", benchfmt.LocalHeader("").CPU},
+		{"cpus", strconv.Itoa(runtime.NumCPU())},
+		{"gomaxprocs", strconv.Itoa(runtime.GOMAXPROCS(0))},
+		{"hostname", hostname},
+	}
+	out := env[:0]
+	for _, e := range env {
+		if e.Value != "" {
+			out = append(out, e)
+		}
+	}
+	return out
+}
+
+// AddTime adds a section about time results, whose steps are labelled by
+// xLabel in the chart.
+func (r *Report) AddTime(name, xLabel string, res *benchkit.TimeResult) {
+	r.sections = append(r.sections, section{name: name, xLabel: xLabel, time: res})
+}
+
+// AddMemory adds a section about memory results.
+func (r *Report) AddMemory(name, xLabel string, res *benchkit.MemResult) {
+	r.sections = append(r.sections, section{name: name, xLabel: xLabel, memory: res})
+}
+
+// Write the report as an HTML page.
+func (r *Report) Write(w io.Writer) error {
+	page := pageData{Title: r.Title, Env: r.Env}
+	for _, s := range r.sections {
+		data, err := r.section(s)
+		if err != nil {
+			return fmt.Errorf("benchreport: section %q: %v", s.name, err)
+		}
+		page.Sections = append(page.Sections, data)
+	}
+	return pageTmpl.Execute(w, page)
+}
+
+// Save the report to an HTML file.
+func (r *Report) Save(filename string) error {
+	f, err := os.Create(filename)
+	if err != nil {
+		return err
+	}
+	if err := r.Write(f); err != nil {
+		_ = f.Close()
+		return err
+	}
+	return f.Close()
+}
+
+func (r *Report) section(s section) (sectionData, error) {
+	data := sectionData{Name: s.name}
+	var (
+		p   *plot.Plot
+		raw bytes.Buffer
+		err error
+	)
+	switch {
+	case s.time != nil:
+		p, err = benchplot.PlotTime(s.name, s.xLabel, s.time, r.LogScale)
+		if err != nil {
+			return data, err
+		}
+		data.Header = []string{"step", "count", "min", "p50", "p90", "p99", "max", "mean", "sd"}
+		for i := range s.time.Each {
+			sum := s.time.Each[i].Summary()
+			data.Rows = append(data.Rows, []string{
+				s.time.StepName(i), strconv.Itoa(sum.Count),
+				sum.Min.String(), sum.P50.String(), sum.P90.String(), sum.P99.String(),
+				sum.Max.String(), sum.Mean.String(), sum.SD.String(),
+			})
+		}
+		err = benchcsv.WriteSamples(&raw, s.time, benchcsv.Config{})
+	default:
+		p, err = benchplot.PlotMemory(s.name, s.xLabel, s.memory, r.LogScale)
+		if err != nil {
+			return data, err
+		}
+		data.Header = []string{"step", "allocated", "allocs", "heap before", "heap after"}
+		m := s.memory
+		for i := 0; i < m.N && i < len(m.BeforeEach) && i < len(m.AfterEach); i++ {
+			before, after := m.BeforeEach[i], m.AfterEach[i]
+			data.Rows = append(data.Rows, []string{
+				m.StepName(i),
+				humanize.Bytes(after.TotalAlloc - before.TotalAlloc),
+				strconv.FormatUint(after.Mallocs-before.Mallocs, 10),
+				humanize.Bytes(before.HeapAlloc),
+				humanize.Bytes(after.HeapAlloc),
+			})
+		}
+		err = benchcsv.WriteMemory(&raw, m, benchcsv.Config{})
+	}
+	if err != nil {
+		return data, err
+	}
+	data.Raw = raw.String()
+	data.Chart, err = inlineSVG(p)
+	return data, err
+}
+
+// inlineSVG renders the plot as an SVG element, to be embedded in HTML.
+func inlineSVG(p *plot.Plot) (template.HTML, error) {
+	wt, err := p.WriterTo(8*vg.Inch, 5*vg.Inch, "svg")
+	if err != nil {
+		return "", err
+	}
+	var buf bytes.Buffer
+	if _, err := wt.WriteTo(&buf); err != nil {
+		return "", err
+	}
+	svg := buf.String()
+	// drop the XML prolog and comments before the svg element
+	if i := strings.Index(svg, "<svg"); i >= 0 {
+		svg = svg[i:]
+	}
+	return template.HTML(svg), nil
+}
+
+type pageData struct {
+	Title    string
+	Env      []Entry
+	Sections []sectionData
+}
+
+type sectionData struct {
+	Name   string
+	Chart  template.HTML
+	Header []string
+	Rows   [][]string
+	Raw    string
+}
+
+var pageTmpl = template.Must(template.New("report").Parse(`<!DOCTYPE html>
+<html>
+<head>
+<meta charset="utf-8">
+<title>{{.Title}}</title>
+<style>
+body { font-family: sans-serif; margin: 2em auto; max-width: 60em; color: #222; }
+table { border-collapse: collapse; margin: 1em 0; }
+th, td { padding: 0.2em 0.8em; border-bottom: 1px solid #ddd; text-align: right; }
+th:first-child, td:first-child { text-align: left; }
+.chart svg { max-width: 100%; height: auto; }
+pre { background: #f6f6f6; padding: 1em; overflow: auto; max-height: 30em; }
+</style>
+</head>
+<body>
+<h1>{{.Title}}</h1>
+{{if .Env}}<table class="env">
+{{range .Env}}<tr><th>{{.Key}}</th><td>{{.Value}}</td></tr>
+{{end}}</table>
+{{end}}{{range .Sections}}<section>
+<h2>{{.Name}}</h2>
+<div class="chart">{{.Chart}}</div>
+<table>
+<tr>{{range .Header}}<th>{{.}}</th>{{end}}</tr>
+{{range .Rows}}<tr>{{range .}}<td>{{.}}</td>{{end}}</tr>
+{{end}}</table>
+<details><summary>Raw data</summary><pre>{{.Raw}}</pre></details>
+</section>
+{{end}}</body>
+</html>
+`))
@@ -0,0 +1,169 @@
+// Package benchtest drives benchkit from the benchmarks of package testing,
+// so that `go test -bench` reports benchkit's statistics.
+package benchtest
+
+import (
+	"sync"
+	"sync/atomic"
+	"testing"
+
+	"github.com/aybabtme/benchkit"
+)
+
+// B wraps a testing.B to track the steps of a benchmark with benchkit.
+type B struct {
+	*testing.B
+	time   benchkit.BenchKit
+	mem    benchkit.BenchKit
+	paused atomic.Bool
+
+	// mu guards the timer of B.
+	mu sync.Mutex
+}
+
+// StopTimer stops timing the benchmark. Until StartTimer is called, the
+// steps of the benchmark are not measured either.
+func (b *B) StopTimer() {
+	b.mu.Lock()
+	b.B.StopTimer()
+	b.mu.Unlock()
+	b.paused.Store(true)
+}
+
+// StartTimer starts timing the benchmark, and measuring its steps.
+func (b *B) StartTimer() {
+	b.paused.Store(false)
+	b.mu.Lock()
+	b.B.StartTimer()
+	b.mu.Unlock()
+}
+
+// Each gives an object that tracks each step of the benchmark. Like for
+// the kits of benchkit, give one to each goroutine doing the work.
+func (b *B) Each() benchkit.BenchEach {
+	return &pausableEach{
+		b:       b,
+		time:    b.time.Each(),
+		mem:     b.mem.Each(),
+		skipped: make(map[int]bool),
+	}
+}
+
+type pausableEach struct {
+	b       *B
+	time    benchkit.BenchEach
+	mem     benchkit.BenchEach
+	skipped map[int]bool
+}
+
+func (p *pausableEach) Before(id int) {
+	if p.b.paused.Load() {
+		p.skipped[id] = true
+		return
+	}
+	delete(p.skipped, id)
+
+	// reading the memory stats stops the world, which isn't part of the
+	// step
+	p.b.mu.Lock()
+	p.b.B.StopTimer()
+	p.mem.Before(id)
+	p.b.B.StartTimer()
+	p.b.mu.Unlock()
+
+	p.time.Before(id)
+}
+
+func (p *pausableEach) After(id int) {
+	if p.skipped[id] || p.b.paused.Load() {
+		return
+	}
+	p.time.After(id)
+
+	p.b.mu.Lock()
+	p.b.B.StopTimer()
+	p.mem.After(id)
+	p.b.B.StartTimer()
+	p.b.mu.Unlock()
+}
+
+// maxRuns caps the durations allocated in advance for each step, since b.N
+// gets large for quick steps. More runs than that reallocate.
+const maxRuns = 1 << 16
+
+// Run benchmarks n steps, measuring both their time and memory with the
+// kits of benchkit.TimeMemory. Each step is repeated b.N times, which body
+// must perform:
+//
+//	func BenchmarkTar(b *testing.B) {
+//		files := GenTarFiles(n, size)
+//		benchtest.Run(b, n, func(b *benchtest.B) {
+//			each := b.Each()
+//			for i := 0; i < b.N; i++ {
+//				for j, file := range files {
+//					each.Before(j)
+//					// file -> tar
+//					each.After(j)
+//				}
+//			}
+//		})
+//	}
+//
+// Once body returns, Run reports the following metrics on b:
+//
+//	p50-ns/step, p90-ns/step, p99-ns/step : percentiles of the durations of all the steps
+//	B/step                                 : bytes allocated by a run of a step, on average
+//	allocs/step                            : allocations made by a run of a step, on average
+//
+// B/step and allocs/step are averaged over the runs of all the steps
+// together. The memory allocated by each step, over all its runs, is found
+// in the TotalEach of the returned memory results.
+//
+// The memory stats are read with the timer of b stopped, so they don't
+// count in ns/op, but the benchmark takes longer to run. Like for the
+// Memory kit, concurrent steps see each other's allocations.
+//
+// The results are returned for further analysis or plotting.
+func Run(b *testing.B, n int, body func(b *B)) *benchkit.TimeMemResult {
+	runs := b.N
+	if runs > maxRuns {
+		runs = maxRuns
+	}
+	memkit, mem := benchkit.Memory(n)
+	timekit, times := benchkit.Time(n, runs)
+	bench := &B{B: b, time: timekit, mem: memkit}
+
+	memkit.Setup()
+	timekit.Setup()
+	memkit.Starting()
+	timekit.Starting()
+	b.ResetTimer()
+	body(bench)
+	b.StopTimer()
+	timekit.Teardown()
+	memkit.Teardown()
+
+	results := &benchkit.TimeMemResult{Time: times, Memory: mem}
+	report(b, results)
+	return results
+}
+
+func report(b *testing.B, results *benchkit.TimeMemResult) {
+	pooled := results.Time.Pooled()
+	p := pooled.Quantiles(0.5, 0.9, 0.99)
+	b.ReportMetric(float64(p[0]), "p50-ns/step")
+	b.ReportMetric(float64(p[1]), "p90-ns/step")
+	b.ReportMetric(float64(p[2]), "p99-ns/step")
+
+	var total benchkit.MemTotal
+	for _, step := range results.Memory.TotalEach {
+		total.Runs += step.Runs
+		total.Bytes += step.Bytes
+		total.Allocs += step.Allocs
+	}
+	if total.Runs == 0 {
+		return
+	}
+	b.ReportMetric(float64(total.Bytes)/float64(total.Runs), "B/step")
+	b.ReportMetric(float64(total.Allocs)/float64(total.Runs), "allocs/step")
+}
@@ -0,0 +1,47 @@
+package benchtest_test
+
+import (
+	"fmt"
+	"sort"
+	"testing"
+
+	"github.com/aybabtme/benchkit/benchtest"
+)
+
+var sink []byte
+
+func ExampleRun() {
+	n := 10
+
+	// this would be a BenchmarkXxx func in a _test.go file
+	benchmark := func(b *testing.B) {
+		benchtest.Run(b, n, func(b *benchtest.B) {
+			each := b.Each()
+			for i := 0; i < b.N; i++ {
+				for j := 0; j < n; j++ {
+					each.Before(j)
+					sink = make([]byte, 64<<10)
+					each.After(j)
+				}
+			}
+		})
+	}
+
+	res := testing.Benchmark(benchmark)
+
+	var units []string
+	for unit := range res.Extra {
+		units = append(units, unit)
+	}
+	sort.Strings(units)
+	fmt.Println(units)
+
+	// averaged over all the runs of the steps
+	fmt.Printf("%.0f B/step\n", res.Extra["B/step"])
+	fmt.Printf("%.0f allocs/step\n", res.Extra["allocs/step"])
+
+	// Output:
+	// [B/step allocs/step p50-ns/step p90-ns/step p99-ns/step]
+	// 65536 B/step
+	// 1 allocs/step
+}
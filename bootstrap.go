@@ -0,0 +1,237 @@
+package benchkit
+
+import (
+	"fmt"
+	"math"
+	"math/rand"
+	"sort"
+	"time"
+
+	"github.com/aybabtme/benchkit/internal/stats"
+)
+
+// Bootstrap estimates confidence intervals for the statistics of a step,
+// by resampling its durations with replacement. The statistics consider
+// all the durations of the step, not only the significant ones.
+//
+// Resampling costs O(n) per resample for a step of n durations. Steps
+// without samples, such as the ones of HistTime, give a zero Interval.
+type Bootstrap struct {
+	// Level of confidence of the intervals, which defaults to 0.95.
+	Level float64
+	// Resamples to draw, which defaults to 10000.
+	Resamples int
+	// Seed of the resampling. The same seed gives the same intervals. If
+	// zero, the resampling is seeded from the clock.
+	Seed int64
+	// BCa corrects the intervals for the bias and skewness of the
+	// statistic (bias-corrected and accelerated bootstrap), instead of
+	// using the plain percentiles of the resampled statistic.
+	BCa bool
+}
+
+// Interval is a confidence interval around an estimate.
+type Interval struct {
+	Estimate time.Duration
+	Lo       time.Duration
+	Hi       time.Duration
+	// Level of confidence that the interval holds the true value.
+	Level float64
+}
+
+func (i Interval) String() string {
+	return fmt.Sprintf("%v [%v, %v] at %g%%", i.Estimate, i.Lo, i.Hi, i.Level*100)
+}
+
+// Mean estimates the mean duration of the step.
+func (b Bootstrap) Mean(step *TimeStep) Interval {
+	sorted := step.Samples()
+	var sum float64
+	for _, dur := range sorted {
+		sum += float64(dur)
+	}
+	n := float64(len(sorted))
+	return b.interval(sorted, sum/n,
+		func(counts []int) float64 {
+			var sum float64
+			for i, c := range counts {
+				sum += float64(c) * float64(sorted[i])
+			}
+			return sum / n
+		},
+		func(i int) float64 {
+			return (sum - float64(sorted[i])) / (n - 1)
+		},
+	)
+}
+
+// Median estimates the median duration of the step.
+func (b Bootstrap) Median(step *TimeStep) Interval {
+	return b.Quantile(step, 0.5)
+}
+
+// Quantile estimates the q-th quantile of the durations of the step, with
+// 0 <= q <= 1, interpolating linearly like TimeStep.Quantile.
+func (b Bootstrap) Quantile(step *TimeStep, q float64) Interval {
+	sorted := step.Samples()
+	return b.interval(sorted, float64(quantile(sorted, q)),
+		func(counts []int) float64 {
+			return countsQuantile(sorted, counts, q)
+		},
+		func(i int) float64 {
+			// the durations without the i-th one are still sorted
+			at := func(rank int) time.Duration {
+				if rank >= i {
+					rank++
+				}
+				return sorted[rank]
+			}
+			pos := q * float64(len(sorted)-2)
+			lo := int(pos)
+			if lo >= len(sorted)-2 {
+				return float64(at(len(sorted) - 2))
+			}
+			frac := pos - float64(lo)
+			return float64(at(lo)) + frac*float64(at(lo+1)-at(lo))
+		},
+	)
+}
+
+// interval resamples the statistic of the durations. resample computes the
+// statistic of a resample, given how many times each duration was drawn,
+// and jackknife computes it without the i-th duration.
+func (b Bootstrap) interval(
+	sorted []time.Duration,
+	estimate float64,
+	resample func(counts []int) float64,
+	jackknife func(i int) float64,
+) Interval {
+	level := b.Level
+	if level <= 0 || level >= 1 {
+		level = 0.95
+	}
+	if len(sorted) < 2 {
+		return Interval{}
+	}
+	resamples := b.Resamples
+	if resamples <= 0 {
+		resamples = 10000
+	}
+	seed := b.Seed
+	if seed == 0 {
+		seed = time.Now().UnixNano()
+	}
+	r := rand.New(rand.NewSource(seed))
+
+	n := len(sorted)
+	counts := make([]int, n)
+	thetas := make([]float64, resamples)
+	for i := range thetas {
+		for j := range counts {
+			counts[j] = 0
+		}
+		for j := 0; j < n; j++ {
+			counts[r.Intn(n)]++
+		}
+		thetas[i] = resample(counts)
+	}
+	sort.Float64s(thetas)
+
+	alpha := (1 - level) / 2
+	loQ, hiQ := alpha, 1-alpha
+	if b.BCa {
+		loQ, hiQ = bcaQuantiles(thetas, estimate, n, jackknife, alpha)
+	}
+	return Interval{
+		Estimate: time.Duration(math.Round(estimate)),
+		Lo:       time.Duration(math.Round(floatQuantile(thetas, loQ))),
+		Hi:       time.Duration(math.Round(floatQuantile(thetas, hiQ))),
+		Level:    level,
+	}
+}
+
+// bcaQuantiles adjusts the quantiles of the resampled statistic to read
+// the interval at, correcting for bias and acceleration.
+func bcaQuantiles(thetas []float64, estimate float64, n int, jackknife func(i int) float64, alpha float64) (float64, float64) {
+	// bias: how many resamples fall below the estimate
+	below := 0.0
+	for _, theta := range thetas {
+		switch {
+		case theta < estimate:
+			below++
+		case theta == estimate:
+			below += 0.5
+		}
+	}
+	B := float64(len(thetas))
+	p := math.Max(1/B, math.Min(1-1/B, below/B))
+	z0 := stats.NormQuantile(p)
+
+	// acceleration: skewness of the jackknife estimates
+	jack := make([]float64, n)
+	var mean float64
+	for i := range jack {
+		jack[i] = jackknife(i)
+		mean += jack[i]
+	}
+	mean /= float64(n)
+	var num, den float64
+	for _, j := range jack {
+		d := mean - j
+		num += d * d * d
+		den += d * d
+	}
+	a := 0.0
+	if den > 0 {
+		a = num / (6 * math.Pow(den, 1.5))
+	}
+
+	adjust := func(q float64) float64 {
+		z := stats.NormQuantile(q)
+		return stats.NormCDF(z0 + (z0+z)/(1-a*(z0+z)))
+	}
+	return adjust(alpha), adjust(1 - alpha)
+}
+
+// countsQuantile is the quantile of the durations drawn counts times each,
+// interpolating linearly like quantile.
+func countsQuantile(sorted []time.Duration, counts []int, q float64) float64 {
+	n := 0
+	for _, c := range counts {
+		n += c
+	}
+	pos := q * float64(n-1)
+	lo := int(pos)
+	frac := pos - float64(lo)
+
+	var loVal, hiVal float64
+	found := false
+	seen := 0
+	for i, c := range counts {
+		seen += c
+		if !found && seen > lo {
+			loVal = float64(sorted[i])
+			found = true
+		}
+		if seen > lo+1 || (frac == 0 && found) {
+			hiVal = float64(sorted[i])
+			break
+		}
+	}
+	if frac == 0 {
+		return loVal
+	}
+	return loVal + frac*(hiVal-loVal)
+}
+
+func floatQuantile(sorted []float64, q float64) float64 {
+	pos := q * float64(len(sorted)-1)
+	i := int(math.Floor(pos))
+	if i >= len(sorted)-1 {
+		return sorted[len(sorted)-1]
+	}
+	if i < 0 {
+		return sorted[0]
+	}
+	return sorted[i] + (pos-float64(i))*(sorted[i+1]-sorted[i])
+}
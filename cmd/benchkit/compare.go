@@ -0,0 +1,53 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io"
+
+	"github.com/aybabtme/benchkit/benchcmp"
+)
+
+func compareCmd(args []string, stdin io.Reader, stdout io.Writer) error {
+	fs := flag.NewFlagSet("compare", flag.ContinueOnError)
+	var (
+		alpha     = fs.Float64("alpha", 0.05, "significance level of the tests")
+		welch     = fs.Bool("welch", false, "use Welch's t-test rather than Mann-Whitney U")
+		threshold = fs.Float64("threshold", 0, "smallest relative change of the mean to flag, such as 0.02 for 2%")
+		fail      = fs.Bool("fail", false, "exit with status 1 if any step regressed")
+		sig       = fs.Bool("significant", false, "only compare the significant durations of the steps, not their outliers")
+	)
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if fs.NArg() != 2 {
+		return fmt.Errorf("compare: want the files of the old and new results, got %v", fs.Args())
+	}
+	if err := stdinOnce("compare", fs.Args()); err != nil {
+		return err
+	}
+	old, err := load(fs.Arg(0), stdin)
+	if err != nil {
+		return err
+	}
+	new, err := load(fs.Arg(1), stdin)
+	if err != nil {
+		return err
+	}
+	if old.Time == nil || new.Time == nil {
+		return fmt.Errorf("compare: both files must hold time results")
+	}
+
+	c := benchcmp.Config{Alpha: *alpha, Threshold: *threshold, Significant: *sig}
+	if *welch {
+		c.Test = benchcmp.Welch
+	}
+	cmp := benchcmp.Compare(old.Time, new.Time, c)
+	if _, err := cmp.WriteTo(stdout); err != nil {
+		return err
+	}
+	if *fail && len(cmp.Regressions()) != 0 {
+		return errFailed
+	}
+	return nil
+}
@@ -0,0 +1,40 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"strings"
+)
+
+const saved = `{"version":1,"kind":"time","n":2,"names":["parse","encode"],"steps":[
+{"filter":{"name":"none"},"samples_ns":[1000000,1100000,1200000,1300000]},
+{"filter":{"name":"none"},"samples_ns":[2000000,2000000,2400000,3000000]}]}`
+
+func Example_summary() {
+	_ = run([]string{"summary"}, strings.NewReader(saved), os.Stdout)
+
+	// Output:
+	// step    count  min  p50     p90     p99      max    mean    sd
+	// parse   4      1ms  1.15ms  1.27ms  1.297ms  1.3ms  1.15ms  111.803µs
+	// encode  4      2ms  2.2ms   2.82ms  2.982ms  3ms    2.35ms  409.267µs
+}
+
+func Example_plotLogScale() {
+	// -σ drops below zero, which the log scale draws at its bottom
+	skewed := `{"version":1,"kind":"time","n":1,"steps":[
+{"filter":{"name":"none"},"samples_ns":[1000,1000,1000,1000000]}]}`
+	err := run([]string{"plot", "-log", "-series", "p50,sd"}, strings.NewReader(skewed), io.Discard)
+	fmt.Println(err)
+
+	// Output:
+	// <nil>
+}
+
+func Example_compareStdinTwice() {
+	err := run([]string{"compare", "-", "-"}, strings.NewReader(saved), os.Stdout)
+	fmt.Println(err)
+
+	// Output:
+	// compare: stdin can only be read once, got [- -]
+}
@@ -0,0 +1,159 @@
+// Command benchkit plots, summarizes and compares the results of benchkit
+// saved as JSON.
+//
+// Usage:
+//
+//	benchkit plot [flags] [file]
+//	benchkit summary [file]
+//	benchkit compare [flags] old new
+//	benchkit report [flags] [files]
+//
+// Results are read from the file, or from stdin if it's - or missing. A
+// file holds either a TimeResult, a MemResult, or a TimeMemResult, as
+// marshaled by encoding/json.
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/aybabtme/benchkit"
+)
+
+const usage = `usage: benchkit <command> [flags] [files]
+
+Commands:
+  plot      plot results as PNG, SVG or PDF
+  summary   print a table of the steps of results
+  compare   compare the steps of two results
+  report    write an HTML report of results
+
+Run 'benchkit <command> -h' for the flags of a command.
+`
+
+// errFailed fails the command after it printed why.
+var errFailed = errors.New("failed")
+
+func main() {
+	err := run(os.Args[1:], os.Stdin, os.Stdout)
+	switch {
+	case err == flag.ErrHelp:
+		os.Exit(2)
+	case err == errFailed:
+		os.Exit(1)
+	case err != nil:
+		fmt.Fprintln(os.Stderr, "benchkit:", err)
+		os.Exit(1)
+	}
+}
+
+func run(args []string, stdin io.Reader, stdout io.Writer) error {
+	if len(args) == 0 {
+		fmt.Fprint(os.Stderr, usage)
+		return flag.ErrHelp
+	}
+	cmd, args := args[0], args[1:]
+	switch cmd {
+	case "plot":
+		return plotCmd(args, stdin, stdout)
+	case "summary":
+		return summaryCmd(args, stdin, stdout)
+	case "compare":
+		return compareCmd(args, stdin, stdout)
+	case "report":
+		return reportCmd(args, stdin, stdout)
+	case "help", "-h", "-help", "--help":
+		fmt.Fprint(os.Stderr, usage)
+		return flag.ErrHelp
+	}
+	return fmt.Errorf("unknown command %q, see 'benchkit help'", cmd)
+}
+
+// results read from a file, any of which may be nil.
+type results struct {
+	Time   *benchkit.TimeResult
+	Memory *benchkit.MemResult
+}
+
+// load the results of a file, or of stdin if the file is - or empty.
+func load(filename string, stdin io.Reader) (*results, error) {
+	r := stdin
+	if filename != "" && filename != "-" {
+		f, err := os.Open(filename)
+		if err != nil {
+			return nil, err
+		}
+		defer f.Close()
+		r = f
+	}
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+
+	var fields map[string]json.RawMessage
+	if err := json.Unmarshal(data, &fields); err != nil {
+		return nil, fmt.Errorf("%s: %v", name(filename), err)
+	}
+	res := &results{}
+	var kind string
+	if raw, ok := fields["kind"]; ok {
+		_ = json.Unmarshal(raw, &kind)
+	}
+	switch kind {
+	case "time":
+		res.Time = &benchkit.TimeResult{}
+		err = json.Unmarshal(data, res.Time)
+	case "memory":
+		res.Memory = &benchkit.MemResult{}
+		err = json.Unmarshal(data, res.Memory)
+	default:
+		// a TimeMemResult, or anything with the same fields
+		err = json.Unmarshal(data, res)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("%s: %v", name(filename), err)
+	}
+	if res.Time == nil && res.Memory == nil {
+		return nil, fmt.Errorf("%s: no results found", name(filename))
+	}
+	return res, nil
+}
+
+func name(filename string) string {
+	if filename == "" || filename == "-" {
+		return "stdin"
+	}
+	return filename
+}
+
+// fileArg is the only file argument of a command, if any.
+func fileArg(fs *flag.FlagSet) (string, error) {
+	switch fs.NArg() {
+	case 0:
+		return "", nil
+	case 1:
+		return fs.Arg(0), nil
+	}
+	return "", fmt.Errorf("%s: too many arguments: %v", fs.Name(), fs.Args())
+}
+
+// stdinOnce makes sure stdin is given at most once among the files of a
+// command, since it can't be read twice.
+func stdinOnce(cmd string, files []string) error {
+	seen := false
+	for _, filename := range files {
+		if filename != "-" {
+			continue
+		}
+		if seen {
+			return fmt.Errorf("%s: stdin can only be read once, got %v", cmd, files)
+		}
+		seen = true
+	}
+	return nil
+}
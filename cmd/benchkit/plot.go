@@ -0,0 +1,129 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/aybabtme/benchkit"
+	"github.com/aybabtme/benchkit/benchplot"
+	"gonum.org/v1/plot"
+	"gonum.org/v1/plot/plotutil"
+	"gonum.org/v1/plot/vg"
+)
+
+// series of the plots of time results, by name. Other quantiles are
+// named like p99.9.
+var series = map[string][]benchplot.TimeSeries{
+	"p50":  {benchplot.P50},
+	"p90":  {benchplot.P90},
+	"p99":  {benchplot.P99},
+	"mean": {benchplot.Mean},
+	"min":  {benchplot.Min},
+	"max":  {benchplot.Max},
+	"sd":   {benchplot.PlusSD, benchplot.MinusSD},
+}
+
+// parseSeries finds the series of a comma separated list of names.
+func parseSeries(names string) ([]benchplot.TimeSeries, error) {
+	var out []benchplot.TimeSeries
+	for i, name := range strings.Split(names, ",") {
+		name = strings.TrimSpace(name)
+		if s, ok := series[name]; ok {
+			out = append(out, s...)
+			continue
+		}
+		p, err := strconv.ParseFloat(strings.TrimPrefix(name, "p"), 64)
+		if !strings.HasPrefix(name, "p") || err != nil || p < 0 || p > 100 {
+			return nil, fmt.Errorf("unknown series %q", name)
+		}
+		c := plotutil.DarkColors[i%len(plotutil.DarkColors)]
+		out = append(out, benchplot.Quantile(p/100, 0.5, c))
+	}
+	return out, nil
+}
+
+func plotCmd(args []string, stdin io.Reader, stdout io.Writer) error {
+	fs := flag.NewFlagSet("plot", flag.ContinueOnError)
+	var (
+		title  = fs.String("title", "", "title of the plot")
+		xLabel = fs.String("xlabel", "Steps", "label of the X axis")
+		logs   = fs.Bool("log", false, "use a log scale for the Y axis")
+		memory = fs.Bool("memory", false, "plot the memory results rather than the time results")
+		chart  = fs.String("chart", "lines", "chart of the time results: lines, box or violin")
+		lines  = fs.String("series", "p50", "comma separated series of time results: p50, p90, p99 or any pNN, mean, min, max, sd")
+		out    = fs.String("o", "-", "file to write the plot to, or - for stdout")
+		format = fs.String("format", "", "png, svg or pdf; guessed from the extension of -o, png by default")
+		width  = fs.Float64("width", 8, "width of the plot, in inches")
+		height = fs.Float64("height", 6, "height of the plot, in inches")
+	)
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	filename, err := fileArg(fs)
+	if err != nil {
+		return err
+	}
+	res, err := load(filename, stdin)
+	if err != nil {
+		return err
+	}
+
+	var p *plot.Plot
+	switch {
+	case *memory || res.Time == nil:
+		if res.Memory == nil {
+			return fmt.Errorf("%s: no memory results to plot", name(filename))
+		}
+		p, err = benchplot.PlotMemory(*title, *xLabel, res.Memory, *logs)
+	default:
+		p, err = plotTime(*chart, *title, *xLabel, res.Time, *logs, *lines)
+	}
+	if err != nil {
+		return err
+	}
+
+	if *format == "" {
+		*format = strings.TrimPrefix(filepath.Ext(*out), ".")
+	}
+	if *format == "" {
+		*format = "png"
+	}
+	wt, err := p.WriterTo(vg.Length(*width)*vg.Inch, vg.Length(*height)*vg.Inch, *format)
+	if err != nil {
+		return err
+	}
+	if *out == "-" {
+		_, err = wt.WriteTo(stdout)
+		return err
+	}
+	f, err := os.Create(*out)
+	if err != nil {
+		return err
+	}
+	if _, err := wt.WriteTo(f); err != nil {
+		_ = f.Close()
+		return err
+	}
+	return f.Close()
+}
+
+func plotTime(chart, title, xLabel string, res *benchkit.TimeResult, logs bool, lines string) (*plot.Plot, error) {
+	switch chart {
+	case "lines":
+		series, err := parseSeries(lines)
+		if err != nil {
+			return nil, err
+		}
+		return benchplot.PlotTime(title, xLabel, res, logs, series...)
+	case "box":
+		return benchplot.PlotBoxes(title, xLabel, res, logs, benchplot.BoxStyle{})
+	case "violin":
+		return benchplot.PlotViolins(title, xLabel, res, logs, benchplot.ViolinStyle{})
+	}
+	return nil, fmt.Errorf("unknown chart %q", chart)
+}
@@ -0,0 +1,48 @@
+package main
+
+import (
+	"flag"
+	"io"
+
+	"github.com/aybabtme/benchkit/benchreport"
+)
+
+func reportCmd(args []string, stdin io.Reader, stdout io.Writer) error {
+	fs := flag.NewFlagSet("report", flag.ContinueOnError)
+	var (
+		title  = fs.String("title", "Benchmarks", "title of the report")
+		xLabel = fs.String("xlabel", "Steps", "label of the X axis of the charts")
+		logs   = fs.Bool("log", false, "use a log scale for the charts")
+		out    = fs.String("o", "-", "file to write the report to, or - for stdout")
+	)
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	files := fs.Args()
+	if len(files) == 0 {
+		files = []string{"-"}
+	}
+	if err := stdinOnce("report", files); err != nil {
+		return err
+	}
+
+	report := benchreport.New(*title)
+	report.LogScale = *logs
+	for _, filename := range files {
+		res, err := load(filename, stdin)
+		if err != nil {
+			return err
+		}
+		if res.Time != nil {
+			report.AddTime(name(filename)+": time", *xLabel, res.Time)
+		}
+		if res.Memory != nil {
+			report.AddMemory(name(filename)+": memory", *xLabel, res.Memory)
+		}
+	}
+
+	if *out == "-" {
+		return report.Write(stdout)
+	}
+	return report.Save(*out)
+}
@@ -0,0 +1,61 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"text/tabwriter"
+
+	"github.com/aybabtme/benchkit"
+	"github.com/dustin/go-humanize"
+)
+
+func summaryCmd(args []string, stdin io.Reader, stdout io.Writer) error {
+	fs := flag.NewFlagSet("summary", flag.ContinueOnError)
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	filename, err := fileArg(fs)
+	if err != nil {
+		return err
+	}
+	res, err := load(filename, stdin)
+	if err != nil {
+		return err
+	}
+
+	tw := tabwriter.NewWriter(stdout, 0, 4, 2, ' ', 0)
+	if res.Time != nil {
+		writeTimeSummary(tw, res.Time)
+	}
+	if res.Time != nil && res.Memory != nil {
+		fmt.Fprintln(tw)
+	}
+	if res.Memory != nil {
+		writeMemSummary(tw, res.Memory)
+	}
+	return tw.Flush()
+}
+
+func writeTimeSummary(w io.Writer, res *benchkit.TimeResult) {
+	fmt.Fprintln(w, "step\tcount\tmin\tp50\tp90\tp99\tmax\tmean\tsd")
+	for i := range res.Each {
+		sum := res.Each[i].Summary()
+		fmt.Fprintf(w, "%s\t%d\t%v\t%v\t%v\t%v\t%v\t%v\t%v\n",
+			res.StepName(i), sum.Count,
+			sum.Min, sum.P50, sum.P90, sum.P99, sum.Max, sum.Mean, sum.SD)
+	}
+}
+
+func writeMemSummary(w io.Writer, res *benchkit.MemResult) {
+	fmt.Fprintln(w, "step\tallocated\tallocs\theap before\theap after")
+	for i := 0; i < res.N && i < len(res.BeforeEach) && i < len(res.AfterEach); i++ {
+		before, after := res.BeforeEach[i], res.AfterEach[i]
+		fmt.Fprintf(w, "%s\t%s\t%d\t%s\t%s\n",
+			res.StepName(i),
+			humanize.Bytes(after.TotalAlloc-before.TotalAlloc),
+			after.Mallocs-before.Mallocs,
+			humanize.Bytes(before.HeapAlloc),
+			humanize.Bytes(after.HeapAlloc))
+	}
+}
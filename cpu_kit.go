@@ -0,0 +1,173 @@
+//go:build unix
+
+package benchkit
+
+import (
+	"runtime"
+	"syscall"
+	"time"
+)
+
+// CPUResult contains the CPU usage of each step of a CPU benchmark.
+type CPUResult struct {
+	N        int
+	Setup    time.Time
+	Start    time.Time
+	Teardown time.Time
+	Each     []CPUStep
+}
+
+// CPUStep contains statistics about the CPU usage of a step of the
+// benchmark. The counters are summed over all the times the step ran.
+type CPUStep struct {
+	// Total is the CPU time spent running the step, in user and system
+	// mode together. On Linux, it's read from the CPU-time clocks of
+	// `clock_gettime(2)`, which are precise.
+	Total TimeStep
+	// User is the CPU time spent running the step in user mode.
+	User TimeStep
+	// System is the CPU time spent in the kernel on behalf of the step.
+	System TimeStep
+
+	// MinorFaults are page faults serviced without any I/O.
+	MinorFaults int64
+	// MajorFaults are page faults that required I/O.
+	MajorFaults int64
+	// VoluntarySwitches are context switches that happened because the step
+	// waited on a resource, such as I/O.
+	VoluntarySwitches int64
+	// InvoluntarySwitches are context switches that happened because the
+	// step was preempted.
+	InvoluntarySwitches int64
+}
+
+type cpuBenchKit struct {
+	n        int
+	setup    time.Time
+	start    time.Time
+	teardown time.Time
+
+	shards *shards[*cpuEach]
+
+	results *CPUResult
+}
+
+func (c *cpuBenchKit) Setup()    { c.setup = time.Now() }
+func (c *cpuBenchKit) Starting() { c.start = time.Now() }
+
+func (c *cpuBenchKit) Each() BenchEach { return c.shards.next() }
+
+func (c *cpuBenchKit) Teardown() {
+	c.teardown = time.Now()
+	c.results.N = c.n
+	c.results.Setup = c.setup
+	c.results.Start = c.start
+	c.results.Teardown = c.teardown
+	c.results.Each = make([]CPUStep, c.n)
+
+	shards := c.shards.list()
+	for i := range c.results.Each {
+		var total, user, sys []time.Duration
+		step := &c.results.Each[i]
+		for _, shard := range shards {
+			for _, sample := range shard.after[i] {
+				total = append(total, sample.total)
+				user = append(user, sample.user)
+				sys = append(sys, sample.sys)
+				step.MinorFaults += sample.minflt
+				step.MajorFaults += sample.majflt
+				step.VoluntarySwitches += sample.nvcsw
+				step.InvoluntarySwitches += sample.nivcsw
+			}
+		}
+		step.Total = NewTimeStep(total, DefaultFilter)
+		step.User = NewTimeStep(user, DefaultFilter)
+		step.System = NewTimeStep(sys, DefaultFilter)
+	}
+}
+
+type cpuSample struct {
+	total  time.Duration
+	user   time.Duration
+	sys    time.Duration
+	minflt int64
+	majflt int64
+	nvcsw  int64
+	nivcsw int64
+}
+
+type cpuEach struct {
+	who    int
+	lock   bool
+	before []syscall.Rusage
+	// cpu is the CPU time when each step last started.
+	cpu   []time.Duration
+	after [][]cpuSample
+}
+
+func newCPUEach(n, m, who int, lock bool) *cpuEach {
+	each := &cpuEach{
+		who:    who,
+		lock:   lock,
+		before: make([]syscall.Rusage, n),
+		cpu:    make([]time.Duration, n),
+		after:  make([][]cpuSample, n),
+	}
+	for i := 0; i < n; i++ {
+		each.after[i] = make([]cpuSample, 0, m)
+	}
+	return each
+}
+
+func (c *cpuEach) Before(id int) {
+	if c.lock {
+		runtime.LockOSThread()
+	}
+	_ = syscall.Getrusage(c.who, &c.before[id])
+	c.cpu[id] = cpuTime(c.who)
+}
+
+func (c *cpuEach) After(id int) {
+	var after syscall.Rusage
+	cpu := cpuTime(c.who)
+	_ = syscall.Getrusage(c.who, &after)
+	if c.lock {
+		runtime.UnlockOSThread()
+	}
+	before := &c.before[id]
+	c.after[id] = append(c.after[id], cpuSample{
+		total:  cpu - c.cpu[id],
+		user:   time.Duration(after.Utime.Nano() - before.Utime.Nano()),
+		sys:    time.Duration(after.Stime.Nano() - before.Stime.Nano()),
+		minflt: int64(after.Minflt - before.Minflt),
+		majflt: int64(after.Majflt - before.Majflt),
+		nvcsw:  int64(after.Nvcsw - before.Nvcsw),
+		nivcsw: int64(after.Nivcsw - before.Nivcsw),
+	})
+}
+
+// CPU will track the CPU time used by each step over exactly n steps,
+// m times for each step, using `getrusage(2)`. Memory is allocated in
+// advance like for Time.
+//
+// The usage is the one of the whole process, so steps running concurrently
+// count each other's CPU time. Combine it with Time to compare the CPU time
+// of the steps with their wall time:
+//
+//	cpukit, cpu := benchkit.CPU(n, m)
+//	timekit, times := benchkit.Time(n, m)
+//	bench := benchkit.Multi(cpukit, timekit)
+func CPU(n, m int) (BenchKit, *CPUResult) {
+	return newCPUBenchKit(n, m, syscall.RUSAGE_SELF, false)
+}
+
+func newCPUBenchKit(n, m, who int, lock bool) (BenchKit, *CPUResult) {
+	bench := &cpuBenchKit{
+		n:       n,
+		results: &CPUResult{},
+		shards: newShards(func() *cpuEach {
+			return newCPUEach(n, m, who, lock)
+		}),
+	}
+	return bench, bench.results
+}
@@ -0,0 +1,39 @@
+package benchkit
+
+import (
+	"syscall"
+	"time"
+	"unsafe"
+)
+
+// ThreadCPU is like CPU, but only tracks the usage of the thread running
+// each step, so steps running concurrently don't count each other's CPU
+// time. The calling goroutine is locked to its thread between Before and
+// After.
+//
+// The User and System times of a thread are counted in clock ticks by
+// Linux, so a step shorter than a tick mostly reads 0 or a whole tick: only
+// their means are meaningful. The Total of each step is precise.
+//
+// ThreadCPU is only available on Linux.
+func ThreadCPU(n, m int) (BenchKit, *CPUResult) {
+	return newCPUBenchKit(n, m, syscall.RUSAGE_THREAD, true)
+}
+
+// the CPU-time clocks of clock_gettime(2)
+const (
+	clockProcessCPUTime = 2
+	clockThreadCPUTime  = 3
+)
+
+// cpuTime reads the CPU time of the process, or of the calling thread if
+// who is RUSAGE_THREAD.
+func cpuTime(who int) time.Duration {
+	clock := clockProcessCPUTime
+	if who == syscall.RUSAGE_THREAD {
+		clock = clockThreadCPUTime
+	}
+	var ts syscall.Timespec
+	_, _, _ = syscall.Syscall(syscall.SYS_CLOCK_GETTIME, uintptr(clock), uintptr(unsafe.Pointer(&ts)), 0)
+	return time.Duration(ts.Nano())
+}
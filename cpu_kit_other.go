@@ -0,0 +1,16 @@
+//go:build unix && !linux
+
+package benchkit
+
+import (
+	"syscall"
+	"time"
+)
+
+// cpuTime reads the CPU time of the process, from getrusage since there's
+// no CPU-time clock to rely on.
+func cpuTime(who int) time.Duration {
+	var ru syscall.Rusage
+	_ = syscall.Getrusage(who, &ru)
+	return time.Duration(ru.Utime.Nano() + ru.Stime.Nano())
+}
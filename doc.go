@@ -33,7 +33,8 @@ consists of 2 methods:
     - Before(i int): call it _before_ starting an atomic part of work.
     - After(i int): call it _after_ finishing an atomic part of work.
 
-In both case, you must ensure that `0 <= i < n`, or you will panic.
+In both case, you must ensure that `0 <= i < n`, or you will panic, unless the
+kit grows (see below).
 
     func doBenchmark(each BenchEach) {
         for i, job := range thingsToDoManyTimes {
@@ -46,6 +47,56 @@ In both case, you must ensure that `0 <= i < n`, or you will panic.
 In the example above, you could use `defer each.After(i)`, however `defer` has
 some overhead and thus, will reduce the precision of your benchmark results.
 
+If your work is spread over many goroutines, have each of them call `Each()`
+to get its own `BenchEach`. A single `BenchEach` must not be used by many
+goroutines at once, and `After` must be called on the same `BenchEach` as
+its `Before`.
+
+    var wg sync.WaitGroup
+    for w := 0; w < workers; w++ {
+        wg.Add(1)
+        go func(each BenchEach) {
+            defer wg.Done()
+            doBenchmark(each)
+        }(bench.Each())
+    }
+    wg.Wait()
+
+If you don't know in advance how many steps there will be, use the `Grow`
+option. The kit then tracks as many steps as it's given, `n` being only a
+hint of how many to allocate in advance. The number of allocations done
+while measuring is found in the `Reallocs` of the result:
+
+    bench, result := benchkit.Time(n, m, benchkit.Grow())
+
+If your steps are better identified by name than by id, use the named kits.
+Names are given ids on the fly, in the order in which they're first seen, and
+are found in the `Names` of the result:
+
+    bench, result := benchkit.NamedTime(n, m)
+    bench.Setup()
+    bench.Starting()
+    each := bench.Each()
+    each.Before("parse")
+    parse()
+    each.After("parse")
+    bench.Teardown()
+    // result.Names == []string{"parse"}
+
+When units of work of the same step overlap, or start in one goroutine and
+finish in another, `Before` and `After` can't tell them apart. Use the
+`BenchTokens` of the kit instead, which hands out a `Token` for each unit of
+work:
+
+    tokens := bench.Each().(BenchTokens)
+    tok := tokens.Begin(i)
+    go func() {
+        job()
+        done <- tok
+    }()
+    // later, on the BenchEach of the goroutine receiving from done
+    other.End(<-done)
+
 The `result` object given with your `bench` object will not be
 populated before your call to `Teardown`.:
 
@@ -60,9 +111,158 @@ Using `result` before `Teardown` will result in:
 
 So don't do that. =)
 
+Or let `Run` call the methods of the kit for you, in the right order. It
+returns the result once it's populated:
+
+    bench, result := benchkit.Time(n, m)
+    result, err := benchkit.Run(bench, result,
+        func() error {
+            // create benchmark data
+            return nil
+        },
+        func(each BenchEach) error {
+            return doBenchmark(each)
+        },
+    )
+
 Memory kit
 
 Collects memory allocation during the benchmark, using `runtime.ReadMemStats`.
+The stats before and after a step are the ones of its last run, while its
+`TotalEach` sums the bytes and allocations of all its runs.
 The measurements are coarse.
+
+Metrics kit
+
+Collects metrics from `runtime/metrics` during the benchmark. By default, the
+heap allocations, live heap, GC cycles, stack and goroutines are sampled, but
+any metric holding a single value can be picked:
+
+    bench, result := benchkit.Metrics(n, "/gc/heap/allocs:bytes", "/sched/goroutines:goroutines")
+
+Reading metrics doesn't stop the world, so this kit disturbs the benchmark
+much less than the memory kit.
+
+Time kit
+
+Collects the duration of each step, possibly many times per step, using
+`time.Now`.
+
+The quantiles of each step are estimated over all its durations, with `q` from
+0 to 1. A `QuantileMethod` gives other estimates than the default linear
+interpolation:
+
+    p50, p90, p99 := step.Quantile(0.5), step.Quantile(0.9), step.Quantile(0.99)
+    hd := benchkit.HarrellDavis.Quantiles(step.Samples(), 0.5, 0.9, 0.99)
+
+The `Min`, `Max`, `Avg` and `SD` of each step only consider its significant
+durations. By default, those are the ones between the 50th and 95th
+percentiles, but the kit can be given another `Filter`: `NoFilter`,
+`QuantileFilter`, `TukeyFilter` or `MADFilter`. The durations left out are
+found in the `Low` and `High` outliers of the step, along with the reason:
+
+    bench, result := benchkit.Time(n, m, benchkit.WithFilter(benchkit.TukeyFilter(1.5)))
+
+The `Summary` of a step gives the count, min, max, mean, SD and the usual
+percentiles over all its durations, outliers included:
+
+    sum := step.Summary()
+    fmt.Println(sum.Min, sum.P50, sum.P99, sum.Max)
+
+To tell whether two steps genuinely differ, a `Bootstrap` estimates
+confidence intervals for the mean, median or any quantile of a step, by
+resampling its durations. Give it a seed for reproducible intervals, and
+set `BCa` to correct them for bias and skewness:
+
+    boot := benchkit.Bootstrap{Level: 0.95, Resamples: 10000, Seed: 1, BCa: true}
+    p90 := boot.Quantile(&step, 0.9)
+    fmt.Println(p90) // 1.2ms [1.1ms, 1.35ms] at 95%
+
+CPU kit
+
+Collects the CPU time spent in user and system mode by each step, along with
+page faults and context switches, using `getrusage(2)`. `CPU` tracks the
+whole process, while `ThreadCPU` tracks the thread running the step and is
+only available on Linux. The `Total` CPU time of each step comes from the
+precise CPU-time clocks of `clock_gettime(2)` on Linux, while the user and
+system times of a thread are counted in clock ticks.
+
+Histogram time kit
+
+Like the time kit, but the durations of each step are counted in a histogram
+with a given number of significant digits, instead of being kept. Memory is
+bounded no matter how long the benchmark runs. The steps of the result answer
+quantiles, min, max, mean and count, within the precision of the histogram:
+
+    bench, result := benchkit.HistTime(n, 3)
+
+Calibrated time kit
+
+Rather than guessing how many times to repeat the steps, let `AutoTime` run
+warmup rounds that are discarded, then repeat the steps until a target wall
+time is reached, or until the mean of every step is precise enough:
+
+    calib := benchkit.Calibration{Warmup: 10, TargetRSE: 0.01}
+    result, err := benchkit.AutoTime(n, calib, func(each BenchEach) error {
+        return doBenchmark(each)
+    })
+    // result.Rounds is the number of rounds that were measured
+
+Span kit
+
+Like the time kit, but steps can be broken into nested spans, which are timed
+as well. The result holds a tree of spans for each step:
+
+    bench, result := benchkit.Spans(n, m)
+    // ...
+    each := bench.Spans()
+    each.Before(i)
+    each.Enter("read")
+    read()
+    each.Exit()
+    each.Enter("decode")
+    decode()
+    each.Exit()
+    each.After(i)
+
+Combining kits
+
+`Multi` runs many kits over the same benchmark. `TimeMemory` combines the
+time and memory kits, taking care to keep the memory measurements out of
+the timings:
+
+    bench, result := benchkit.TimeMemory(n, m)
+    // ...
+    bench.Teardown()
+    // use result.Time and result.Memory
+
+Saving results
+
+Time and memory results can be saved as JSON, with a versioned schema
+described by `SchemaVersion`. Unmarshaled results keep their samples,
+histograms and filters, so they answer the same questions as the original
+ones:
+
+    data, err := json.Marshal(result.Time)
+    // ...
+    var saved benchkit.TimeResult
+    err = json.Unmarshal(data, &saved)
+
+Package benchcsv writes the results as CSV or TSV tables, to be opened in
+spreadsheets or data frames.
+
+Package benchfmt writes the results as `go test -bench` lines, to be fed
+to benchstat and the other Go tools. It also parses `go test -bench`
+output into results, to plot old benchmark logs.
+
+The benchkit command plots, summarizes and compares results saved as JSON.
+Package benchreport writes results as a self-contained HTML report.
+
+Comparing runs
+
+Package benchcmp compares a baseline with a new run, step by step, and
+flags the steps that got significantly slower or faster. Package baseline
+saves a run as a baseline, and package baselinetest fails tests when later
+runs regress beyond some tolerances.
 */
 package benchkit
@@ -0,0 +1,33 @@
+//go:build unix
+
+package benchkit_test
+
+import (
+	"fmt"
+
+	"github.com/aybabtme/benchkit"
+)
+
+func ExampleCPU() {
+	n, times := 10, 100
+
+	cpukit, cpu := benchkit.CPU(n, times)
+	timekit, wall := benchkit.Time(n, times)
+
+	_, _ = benchkit.Run(benchkit.Multi(cpukit, timekit), cpu, nil, func(each benchkit.BenchEach) error {
+		for repeat := 0; repeat < times; repeat++ {
+			for i := 0; i < n; i++ {
+				each.Before(i)
+				// do stuff
+				each.After(i)
+			}
+		}
+		return nil
+	})
+
+	// compare cpu.Each[i].Total.Avg with wall.Each[i].Avg
+	fmt.Printf("cpu steps=%d, wall steps=%d\n", cpu.N, wall.N)
+
+	// Output:
+	// cpu steps=10, wall steps=10
+}
@@ -3,10 +3,13 @@ package benchkit_test
 import (
 	"archive/tar"
 	"bytes"
+	"encoding/json"
+	"errors"
 	"fmt"
 	"os"
 	"runtime"
 	"strconv"
+	"sync"
 	"time"
 
 	"github.com/aybabtme/benchkit"
@@ -14,6 +17,43 @@ import (
 	"github.com/dustin/randbo"
 )
 
+func ExampleRun() {
+	n, size := 5, 1000000
+	buf := bytes.NewBuffer(nil)
+	var files []TarFile
+
+	kit, results := benchkit.Time(n, 1)
+	results, err := benchkit.Run(kit, results,
+		func() error {
+			files = GenTarFiles(n, size)
+			return nil
+		},
+		func(each benchkit.BenchEach) error {
+			tarw := tar.NewWriter(buf)
+			for i, file := range files {
+				each.Before(i)
+				if err := tarw.WriteHeader(file.TarHeader()); err != nil {
+					return err
+				}
+				if _, err := tarw.Write(file.Data()); err != nil {
+					return err
+				}
+				each.After(i)
+			}
+			return tarw.Close()
+		},
+	)
+	if err != nil {
+		fmt.Println(err)
+		return
+	}
+
+	fmt.Printf("steps=%d\n", results.N)
+
+	// Output:
+	// steps=5
+}
+
 func ExampleBench() {
 	mem := benchkit.Bench(benchkit.Memory(10)).Each(func(each benchkit.BenchEach) {
 		for i := 0; i < 10; i++ {
@@ -40,6 +80,581 @@ func ExampleBench() {
 	_ = times
 }
 
+func ExampleTime_parallel() {
+	n, workers := 10, 4
+
+	timekit, results := benchkit.Time(n, 100)
+	timekit.Setup()
+	timekit.Starting()
+
+	var wg sync.WaitGroup
+	for w := 0; w < workers; w++ {
+		wg.Add(1)
+		go func(each benchkit.BenchEach) {
+			defer wg.Done()
+			for repeat := 0; repeat < 25; repeat++ {
+				for i := 0; i < n; i++ {
+					each.Before(i)
+					// do stuff
+					each.After(i)
+				}
+			}
+		}(timekit.Each())
+	}
+	wg.Wait()
+
+	timekit.Teardown()
+
+	// the samples of all the workers are merged
+	fmt.Printf("steps=%d\n", results.N)
+	for i, step := range results.Each {
+		if step.Count() != workers*25 {
+			fmt.Printf("step %d: %d samples\n", i, step.Count())
+		}
+	}
+
+	// Output:
+	// steps=10
+}
+
+func ExampleTime_afterWithoutBefore() {
+	timekit, _ := benchkit.Time(1, 1)
+
+	// each call to Each gives another BenchEach, which didn't see Before
+	timekit.Each().Before(0)
+	defer func() { fmt.Println(recover()) }()
+	timekit.Each().After(0)
+
+	// Output:
+	// benchkit: After called without a matching Before on the same BenchEach
+}
+
+func ExampleBenchTokens() {
+	n := 10
+
+	timekit, results := benchkit.Time(n, 1)
+	timekit.Setup()
+	timekit.Starting()
+
+	// start all the jobs at once, then finish them in another goroutine
+	started := make(chan benchkit.Token, n)
+	tokens := timekit.Each().(benchkit.BenchTokens)
+	for i := 0; i < n; i++ {
+		started <- tokens.Begin(i)
+	}
+	close(started)
+
+	done := make(chan struct{})
+	go func(each benchkit.BenchTokens) {
+		defer close(done)
+		for tok := range started {
+			// do stuff
+			each.End(tok)
+		}
+	}(timekit.Each().(benchkit.BenchTokens))
+	<-done
+
+	timekit.Teardown()
+
+	fmt.Printf("steps=%d\n", results.N)
+
+	// Output:
+	// steps=10
+}
+
+func ExampleTimeMemory() {
+	n, times := 10, 100
+
+	kit, results := benchkit.TimeMemory(n, times)
+	results, _ = benchkit.Run(kit, results, nil, func(each benchkit.BenchEach) error {
+		for repeat := 0; repeat < times; repeat++ {
+			for i := 0; i < n; i++ {
+				each.Before(i)
+				// do stuff
+				each.After(i)
+			}
+		}
+		return nil
+	})
+
+	fmt.Printf("time steps=%d, memory steps=%d\n", results.Time.N, results.Memory.N)
+
+	// Output:
+	// time steps=10, memory steps=10
+}
+
+func ExampleTimeMemory_tokens() {
+	n := 3
+
+	kit, results := benchkit.TimeMemory(n, 1)
+	kit.Setup()
+	kit.Starting()
+
+	// like the kits it combines, TimeMemory hands out tokens
+	tokens := kit.Each().(benchkit.BenchTokens)
+	var started []benchkit.Token
+	for i := 0; i < n; i++ {
+		started = append(started, tokens.Begin(i))
+	}
+	for _, tok := range started {
+		// do stuff
+		tokens.End(tok)
+	}
+	kit.Teardown()
+
+	fmt.Printf("time samples=%d, memory steps=%d\n", results.Time.Each[0].Count(), results.Memory.N)
+
+	// Output:
+	// time samples=1, memory steps=3
+}
+
+func ExampleNamedTime() {
+	steps := []string{"parse", "validate", "encode"}
+
+	timekit, results := benchkit.NamedTime(len(steps), 100)
+	timekit.Setup()
+	timekit.Starting()
+	each := timekit.Each()
+	for repeat := 0; repeat < 100; repeat++ {
+		for _, step := range steps {
+			each.Before(step)
+			// do stuff
+			each.After(step)
+		}
+	}
+	timekit.Teardown()
+
+	fmt.Println(results.Names)
+	fmt.Printf("validate is step %d\n", results.Index("validate"))
+
+	// Output:
+	// [parse validate encode]
+	// validate is step 1
+}
+
+func ExampleGrow() {
+	// a stream of unknown length
+	jobs := make(chan int)
+	go func() {
+		defer close(jobs)
+		for i := 0; i < 5; i++ {
+			jobs <- i
+		}
+	}()
+
+	// allocate 2 steps of 10 samples in advance
+	timekit, results := benchkit.Time(2, 10, benchkit.Grow())
+	timekit.Setup()
+	timekit.Starting()
+	each := timekit.Each()
+	for i := range jobs {
+		each.Before(i)
+		// do stuff
+		each.After(i)
+	}
+	timekit.Teardown()
+
+	fmt.Printf("steps=%d reallocs=%d\n", results.N, results.Reallocs)
+
+	// Output:
+	// steps=5 reallocs=2
+}
+
+func ExampleSpans() {
+	n, times := 5, 10
+
+	spankit, results := benchkit.Spans(n, times)
+	spankit.Setup()
+	spankit.Starting()
+	each := spankit.Spans()
+	for repeat := 0; repeat < times; repeat++ {
+		for i := 0; i < n; i++ {
+			each.Before(i)
+			each.Enter("read")
+			// read stuff
+			each.Exit()
+			each.Enter("write")
+			each.Enter("encode")
+			// encode stuff
+			each.Exit()
+			each.Exit()
+			each.After(i)
+		}
+	}
+	spankit.Teardown()
+
+	var walk func(span benchkit.Span, depth int)
+	walk = func(span benchkit.Span, depth int) {
+		fmt.Printf("%*s%s x%d\n", 2*depth, "", span.Name, span.Time.Count())
+		for _, child := range span.Children {
+			walk(child, depth+1)
+		}
+	}
+	walk(results.Each[0], 0)
+
+	// Output:
+	// 0 x10
+	//   read x10
+	//   write x10
+	//     encode x10
+}
+
+func ExampleSpans_outsideStep() {
+	spankit, _ := benchkit.Spans(1, 1)
+	each := spankit.Spans()
+
+	defer func() { fmt.Println(recover()) }()
+	// spans only break steps apart, so Before comes first
+	each.Enter("read")
+
+	// Output:
+	// benchkit: Enter called outside of a step, without a matching Before
+}
+
+func ExampleAutoTime() {
+	n := 10
+
+	calib := benchkit.Calibration{
+		Warmup:    5,
+		Target:    time.Hour,
+		TargetRSE: 0.01,
+		MaxRounds: 20,
+	}
+	results, err := benchkit.AutoTime(n, calib, func(each benchkit.BenchEach) error {
+		for i := 0; i < n; i++ {
+			each.Before(i)
+			// do stuff
+			each.After(i)
+		}
+		return nil
+	})
+	if err != nil {
+		fmt.Println(err)
+		return
+	}
+
+	// rounds stop early once the timings are precise enough
+	fmt.Printf("warmup=%d, measured at most %d rounds: %v\n",
+		results.Warmup, calib.MaxRounds, results.Rounds <= calib.MaxRounds)
+	fmt.Printf("steps=%d, samples per step=rounds: %v\n",
+		results.Time.N, results.Time.Each[0].Count() == results.Rounds)
+
+	// Output:
+	// warmup=5, measured at most 20 rounds: true
+	// steps=10, samples per step=rounds: true
+}
+
+func ExampleCalibration_targetRSE() {
+	n := 2
+
+	calib := benchkit.Calibration{
+		Target:    time.Hour,
+		TargetRSE: 0.05,
+		MaxRounds: 1000,
+	}
+	results, err := benchkit.AutoTime(n, calib, func(each benchkit.BenchEach) error {
+		for i := 0; i < n; i++ {
+			each.Before(i)
+			time.Sleep(time.Millisecond)
+			each.After(i)
+		}
+		return nil
+	})
+	if err != nil {
+		fmt.Println(err)
+		return
+	}
+
+	// steady steps are precise enough long before MaxRounds
+	fmt.Println(results.Rounds < calib.MaxRounds, results.RSE <= calib.TargetRSE)
+
+	// Output:
+	// true true
+}
+
+func ExampleCalibration_warmup() {
+	n := 2
+
+	calls := 0
+	calib := benchkit.Calibration{Warmup: 3, MaxRounds: 5}
+	results, err := benchkit.AutoTime(n, calib, func(each benchkit.BenchEach) error {
+		calls++
+		for i := 0; i < n; i++ {
+			each.Before(i)
+			if calls <= calib.Warmup {
+				// cold caches
+				time.Sleep(50 * time.Millisecond)
+			}
+			each.After(i)
+		}
+		return nil
+	})
+	if err != nil {
+		fmt.Println(err)
+		return
+	}
+
+	// the slow warmup rounds ran, but their timings were discarded
+	step := results.Time.Each[0]
+	fmt.Println(calls, results.Warmup, results.Rounds, step.Count())
+	fmt.Println(step.Quantile(1) < 50*time.Millisecond)
+
+	// Output:
+	// 8 3 5 5
+	// true
+}
+
+func ExampleAutoTime_warmupError() {
+	calls := 0
+	calib := benchkit.Calibration{Warmup: 3}
+	results, err := benchkit.AutoTime(1, calib, func(each benchkit.BenchEach) error {
+		if calls++; calls == 2 {
+			return errors.New("out of disk")
+		}
+		return nil
+	})
+	fmt.Println(err, results.Warmup, results.Rounds, results.Time.N)
+
+	// Output:
+	// out of disk 1 0 1
+}
+
+func ExampleQuantileMethod() {
+	var sorted []time.Duration
+	for i := 1; i <= 10; i++ {
+		sorted = append(sorted, time.Duration(i)*time.Millisecond)
+	}
+
+	for _, method := range []benchkit.QuantileMethod{
+		benchkit.Linear,
+		benchkit.NearestRank,
+		benchkit.HarrellDavis,
+	} {
+		fmt.Println(method.Quantiles(sorted, 0, 0.5, 0.9, 1))
+	}
+
+	// Output:
+	// [1ms 5.5ms 9.1ms 10ms]
+	// [1ms 5ms 9ms 10ms]
+	// [1ms 5.5ms 9.435115ms 10ms]
+}
+
+func ExampleTukeyFilter() {
+	sorted := []time.Duration{
+		1 * time.Millisecond,
+		10 * time.Millisecond,
+		11 * time.Millisecond,
+		12 * time.Millisecond,
+		13 * time.Millisecond,
+		14 * time.Millisecond,
+		100 * time.Millisecond,
+	}
+
+	lo, hi, low, high := benchkit.TukeyFilter(1.5).Window(sorted)
+	fmt.Println(sorted[lo:hi])
+	fmt.Println(sorted[:lo], low)
+	fmt.Println(sorted[hi:], high)
+
+	// Use it in a kit with:
+	//   benchkit.Time(n, m, benchkit.WithFilter(benchkit.TukeyFilter(1.5)))
+
+	// Output:
+	// [10ms 11ms 12ms 13ms 14ms]
+	// [1ms] below Tukey fence 6ms (k=1.5)
+	// [100ms] above Tukey fence 18ms (k=1.5)
+}
+
+func ExampleTimeStep_Summary() {
+	var durs []time.Duration
+	for i := 1; i <= 10; i++ {
+		durs = append(durs, time.Duration(i)*time.Millisecond)
+	}
+	durs = append(durs, 100*time.Millisecond)
+	step := benchkit.NewTimeStep(durs, benchkit.TukeyFilter(1.5))
+
+	// the outlier is left out of the significant durations, but not out
+	// of the summary
+	sum := step.Summary()
+	fmt.Println(step.Max, step.Avg)
+	fmt.Println(sum.Max, sum.Mean, sum.P50)
+
+	// Output:
+	// 10ms 5.5ms
+	// 100ms 14.090909ms 6ms
+}
+
+func ExampleHistTime() {
+	n, times := 10, 100000
+
+	// durations are known within 0.1% of their value
+	histkit, results := benchkit.HistTime(n, 3)
+	histkit.Setup()
+	histkit.Starting()
+	each := histkit.Each()
+	for repeat := 0; repeat < times; repeat++ {
+		for i := 0; i < n; i++ {
+			each.Before(i)
+			// do stuff
+			each.After(i)
+		}
+	}
+	histkit.Teardown()
+
+	step := results.Each[0]
+	_ = step.Quantiles(0.5, 0.9, 0.99, 0.999)
+
+	fmt.Printf("step 0 ran %d times, kept %d samples\n", step.Count(), len(step.Samples()))
+
+	// Output:
+	// step 0 ran 100000 times, kept 0 samples
+}
+
+func ExampleHistTime_grow() {
+	histkit, results := benchkit.HistTime(0, 3, benchkit.Grow())
+	histkit.Setup()
+	histkit.Starting()
+
+	// each goroutine sees a different number of steps
+	var wg sync.WaitGroup
+	for _, steps := range []int{10, 3} {
+		wg.Add(1)
+		go func(each benchkit.BenchEach, steps int) {
+			defer wg.Done()
+			for i := 0; i < steps; i++ {
+				each.Before(i)
+				each.After(i)
+			}
+		}(histkit.Each(), steps)
+	}
+	wg.Wait()
+	histkit.Teardown()
+
+	fmt.Println(results.N, results.Each[9].Count(), results.Each[2].Count())
+
+	// Output:
+	// 10 1 2
+}
+
+func ExampleTimeResult_Pooled_mixed() {
+	// a saved result can mix steps counted in a histogram with steps
+	// holding their samples
+	data := `{"version":1,"kind":"time","n":2,"steps":[
+		{"histogram":{"sub_bits":11,"buckets":[[1000,2]],"count":2,"min_ns":1000,"max_ns":1000,"sum":2000,"sum_sq":2000000}},
+		{"filter":{"name":"none"},"samples_ns":[3000,5000]}
+	]}`
+	var result benchkit.TimeResult
+	if err := json.Unmarshal([]byte(data), &result); err != nil {
+		panic(err)
+	}
+
+	pooled := result.Pooled()
+	fmt.Println(pooled.Count(), pooled.Min, pooled.Max, pooled.Avg)
+
+	// Output:
+	// 4 1µs 5µs 2.5µs
+}
+
+func ExampleBootstrap() {
+	var durs []time.Duration
+	for i := 1; i <= 20; i++ {
+		durs = append(durs, time.Duration(i*i)*time.Millisecond)
+	}
+	step := benchkit.NewTimeStep(durs, benchkit.NoFilter)
+
+	// a seed makes the intervals reproducible
+	boot := benchkit.Bootstrap{Level: 0.9, Resamples: 2000, Seed: 42}
+	fmt.Println(boot.Mean(&step))
+	fmt.Println(boot.Median(&step))
+
+	boot.BCa = true
+	fmt.Println(boot.Quantile(&step, 0.9))
+
+	// Output:
+	// 143.5ms [99.395ms, 190.0125ms] at 90%
+	// 110.5ms [49ms, 196ms] at 90%
+	// 327.7ms [231.4ms, 400ms] at 90%
+}
+
+func ExampleTimeResult_MarshalJSON() {
+	result := &benchkit.TimeResult{N: 1, Names: []string{"parse"}}
+	durs := []time.Duration{3 * time.Millisecond, 1 * time.Millisecond, 2 * time.Millisecond, 50 * time.Millisecond}
+	result.Each = append(result.Each, benchkit.NewTimeStep(durs, benchkit.TukeyFilter(1.5)))
+
+	data, _ := json.Marshal(result)
+	fmt.Println(string(data))
+
+	var decoded benchkit.TimeResult
+	_ = json.Unmarshal(data, &decoded)
+	parse := decoded.Each[decoded.Index("parse")]
+	fmt.Println(parse.Quantile(0.5), parse.Significant, parse.High.Reason)
+
+	// Output:
+	// {"version":1,"kind":"time","n":1,"setup":"0001-01-01T00:00:00Z","start":"0001-01-01T00:00:00Z","teardown":"0001-01-01T00:00:00Z","names":["parse"],"reallocs":0,"steps":[{"filter":{"name":"tukey","k":1.5},"samples_ns":[1000000,2000000,3000000,50000000]}]}
+	// 2.5ms [1ms 2ms 3ms] above Tukey fence 34.25ms (k=1.5)
+}
+
+// warmupFilter leaves out the first duration of a step.
+type warmupFilter struct{}
+
+func (warmupFilter) Window(sorted []time.Duration) (int, int, string, string) {
+	return 1, len(sorted), "warmup", ""
+}
+
+func ExampleTimeResult_MarshalJSON_customFilter() {
+	result := &benchkit.TimeResult{N: 1}
+	durs := []time.Duration{3 * time.Millisecond, 1 * time.Millisecond, 2 * time.Millisecond}
+	result.Each = append(result.Each, benchkit.NewTimeStep(durs, warmupFilter{}))
+
+	// the window of the filter is saved rather than the filter
+	data, _ := json.Marshal(result)
+	var decoded benchkit.TimeResult
+	_ = json.Unmarshal(data, &decoded)
+	step := decoded.Each[0]
+	fmt.Println(step.Significant, step.Low.Durations, step.Low.Reason)
+
+	// Output:
+	// [2ms 3ms] [1ms] warmup
+}
+
+func ExampleTimeResult_MarshalJSON_byValue() {
+	kit, results := benchkit.Time(1, 3)
+	kit.Setup()
+	kit.Starting()
+	each := kit.Each()
+	for i := 0; i < 3; i++ {
+		each.Before(0)
+		each.After(0)
+	}
+	kit.Teardown()
+
+	// results held by value keep their samples too
+	saved := struct{ Run benchkit.TimeResult }{Run: *results}
+	data, _ := json.Marshal(saved)
+
+	var loaded struct{ Run benchkit.TimeResult }
+	err := json.Unmarshal(data, &loaded)
+	fmt.Println(err, loaded.Run.Each[0].Count())
+
+	// Output:
+	// <nil> 3
+}
+
+func ExampleTimeResult_UnmarshalJSON() {
+	for _, data := range []string{
+		`{"version":1,"kind":"time","n":1,"steps":[{"histogram":{"sub_bits":64,"buckets":[[1,1]],"count":1}}]}`,
+		`{"version":1,"kind":"time","n":1,"steps":[{"histogram":{"sub_bits":11,"buckets":[[123456789,1]],"count":1}}]}`,
+		`{"version":2,"kind":"time","n":1,"steps":[]}`,
+	} {
+		var result benchkit.TimeResult
+		fmt.Println(json.Unmarshal([]byte(data), &result))
+	}
+
+	// Output:
+	// benchkit: step 0: invalid histogram sub_bits 64
+	// benchkit: step 0: histogram bucket 123456789 out of range
+	// benchkit: unsupported schema version 2, want 1
+}
+
 func ExampleMemory() {
 	n := 5
 	size := 1000000
@@ -88,6 +703,63 @@ func ExampleMemory() {
 	// teardown=26 MB
 }
 
+func ExampleMemTotal() {
+	n, times := 2, 10
+
+	memkit, results := benchkit.Memory(n)
+	results, _ = benchkit.Run(memkit, results, nil, func(each benchkit.BenchEach) error {
+		for repeat := 0; repeat < times; repeat++ {
+			for i := 0; i < n; i++ {
+				each.Before(i)
+				sink = make([]byte, (i+1)<<10)
+				each.After(i)
+			}
+		}
+		return nil
+	})
+
+	// the totals cover every run, not only the last one
+	for i, total := range results.TotalEach {
+		fmt.Printf("step %d: %d runs, %d B/run\n", i, total.Runs, total.Bytes/uint64(total.Runs))
+	}
+
+	// Output:
+	// step 0: 10 runs, 1024 B/run
+	// step 1: 10 runs, 2048 B/run
+}
+
+var sink []byte
+
+func ExampleMetrics() {
+	n := 5
+	size := 1 << 20
+
+	metrickit, results := benchkit.Metrics(n)
+
+	metrickit.Setup()
+	metrickit.Starting()
+	each := metrickit.Each()
+	for i := 0; i < n; i++ {
+		each.Before(i)
+		sink = make([]byte, size)
+		each.After(i)
+	}
+	metrickit.Teardown()
+
+	allocs := results.Index("/gc/heap/allocs:bytes")
+	for i := 0; i < results.N; i++ {
+		delta := results.AfterEach[i][allocs] - results.BeforeEach[i][allocs]
+		fmt.Printf("  %d  allocated at least %s: %v\n", i, humanize.Bytes(uint64(size)), delta >= float64(size))
+	}
+
+	// Output:
+	//   0  allocated at least 1.0 MB: true
+	//   1  allocated at least 1.0 MB: true
+	//   2  allocated at least 1.0 MB: true
+	//   3  allocated at least 1.0 MB: true
+	//   4  allocated at least 1.0 MB: true
+}
+
 func effectMem(mem *runtime.MemStats) string {
 	effectMem := mem.Sys - mem.HeapReleased
 	return humanize.Bytes(effectMem)
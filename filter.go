@@ -0,0 +1,136 @@
+package benchkit
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"time"
+)
+
+// Filter selects the significant durations of a step, leaving out its
+// outliers. The Min, Max, Avg and SD of a TimeStep only consider its
+// significant durations.
+type Filter interface {
+	// Window returns the bounds of the significant durations, sorted[lo:hi],
+	// along with the reasons why the durations below and above them are
+	// left out. The durations are sorted in increasing order.
+	Window(sorted []time.Duration) (lo, hi int, low, high string)
+}
+
+// Outliers are durations of a step left out of its significant durations.
+type Outliers struct {
+	// Durations left out, in increasing order.
+	Durations []time.Duration
+	// Reason why they were left out.
+	Reason string
+}
+
+// DefaultFilter is used by kits that aren't given a filter. It only keeps
+// the durations between the 50th and 95th percentiles.
+var DefaultFilter Filter = QuantileFilter(0.5, 0.95)
+
+// NoFilter keeps all the durations.
+var NoFilter Filter = noFilter{}
+
+type noFilter struct{}
+
+func (noFilter) Window(sorted []time.Duration) (int, int, string, string) {
+	return 0, len(sorted), "", ""
+}
+
+// QuantileFilter keeps the durations between the from and to quantiles,
+// with 0 <= from <= to <= 1, like the argument of TimeStep.Quantile:
+// QuantileFilter(0.5, 0.95) keeps the durations between p50 and p95.
+func QuantileFilter(from, to float64) Filter {
+	return quantileFilter{from: from, to: to}
+}
+
+type quantileFilter struct{ from, to float64 }
+
+func (p quantileFilter) Window(sorted []time.Duration) (int, int, string, string) {
+	n := float64(len(sorted))
+	lo := int(math.Floor(p.from * n))
+	hi := int(math.Ceil(p.to * n))
+	return lo, hi,
+		fmt.Sprintf("below p%g", p.from*100),
+		fmt.Sprintf("above p%g", p.to*100)
+}
+
+// TukeyFilter keeps the durations within Tukey's fences, which are k times
+// the interquartile range below the first quartile and above the third.
+// The usual k is 1.5.
+func TukeyFilter(k float64) Filter {
+	return tukeyFilter{k: k}
+}
+
+type tukeyFilter struct{ k float64 }
+
+func (t tukeyFilter) Window(sorted []time.Duration) (int, int, string, string) {
+	if len(sorted) == 0 {
+		return 0, 0, "", ""
+	}
+	q1, q3 := quantile(sorted, 0.25), quantile(sorted, 0.75)
+	iqr := q3 - q1
+	low := q1 - time.Duration(t.k*float64(iqr))
+	high := q3 + time.Duration(t.k*float64(iqr))
+	lo, hi := window(sorted, low, high)
+	return lo, hi,
+		fmt.Sprintf("below Tukey fence %v (k=%g)", low, t.k),
+		fmt.Sprintf("above Tukey fence %v (k=%g)", high, t.k)
+}
+
+// MADFilter keeps the durations within k median absolute deviations of the
+// median. The deviation is scaled to be consistent with the standard
+// deviation of a normal distribution, so a k of 3 is a common choice.
+func MADFilter(k float64) Filter {
+	return madFilter{k: k}
+}
+
+type madFilter struct{ k float64 }
+
+func (m madFilter) Window(sorted []time.Duration) (int, int, string, string) {
+	if len(sorted) == 0 {
+		return 0, 0, "", ""
+	}
+	median := quantile(sorted, 0.5)
+	deviations := make(durationSlice, len(sorted))
+	for i, dur := range sorted {
+		deviations[i] = dur - median
+		if deviations[i] < 0 {
+			deviations[i] = -deviations[i]
+		}
+	}
+	sort.Sort(&deviations)
+	// 1.4826 scales the MAD to the standard deviation of a normal distribution
+	mad := 1.4826 * float64(quantile(deviations, 0.5))
+	low := median - time.Duration(m.k*mad)
+	high := median + time.Duration(m.k*mad)
+	lo, hi := window(sorted, low, high)
+	return lo, hi,
+		fmt.Sprintf("more than %g MADs below median %v", m.k, median),
+		fmt.Sprintf("more than %g MADs above median %v", m.k, median)
+}
+
+// window finds the bounds of the durations between low and high, inclusive.
+func window(sorted []time.Duration, low, high time.Duration) (int, int) {
+	lo := sort.Search(len(sorted), func(i int) bool { return sorted[i] >= low })
+	hi := sort.Search(len(sorted), func(i int) bool { return sorted[i] > high })
+	return lo, hi
+}
+
+// quantile interpolates linearly between the closest ranks of q.
+func quantile(sorted []time.Duration, q float64) time.Duration {
+	if len(sorted) == 0 {
+		return 0
+	}
+	pos := q * float64(len(sorted)-1)
+	i := int(math.Floor(pos))
+	if i >= len(sorted)-1 {
+		return sorted[len(sorted)-1]
+	}
+	if i < 0 {
+		return sorted[0]
+	}
+	frac := pos - float64(i)
+	return sorted[i] + time.Duration(math.Round(frac*float64(sorted[i+1]-sorted[i])))
+}
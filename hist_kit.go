@@ -0,0 +1,359 @@
+package benchkit
+
+import (
+	"math"
+	"math/bits"
+	"time"
+)
+
+// histogram counts durations in log-linear buckets, like an HdrHistogram:
+// each power of two is split in the same number of sub-buckets, so the
+// relative precision of a duration is the same at any magnitude.
+type histogram struct {
+	subBits uint
+	counts  []uint64
+	count   uint64
+	min     time.Duration
+	max     time.Duration
+	sum     float64
+	sumSq   float64
+}
+
+func newHistogram(sigfigs int) *histogram {
+	if sigfigs < 1 {
+		sigfigs = 1
+	}
+	if sigfigs > 5 {
+		sigfigs = 5
+	}
+	// enough sub-buckets to tell apart durations that differ by
+	// less than 10^-sigfigs of their value
+	subBits := uint(math.Ceil(math.Log2(2 * math.Pow10(sigfigs))))
+	return &histogram{
+		subBits: subBits,
+		counts:  make([]uint64, 1<<subBits),
+	}
+}
+
+func (h *histogram) index(v uint64) int {
+	sub := uint64(1) << h.subBits
+	if v < sub {
+		return int(v)
+	}
+	shift := uint(bits.Len64(v)) - h.subBits
+	return int(sub + uint64(shift-1)*(sub/2) + (v>>shift - sub/2))
+}
+
+// bucket returns the lowest value counted at idx, and the number of
+// values counted there.
+func (h *histogram) bucket(idx int) (lowest, width uint64) {
+	sub := uint64(1) << h.subBits
+	i := uint64(idx)
+	if i < sub {
+		return i, 1
+	}
+	k := i - sub
+	shift := k/(sub/2) + 1
+	return (k%(sub/2) + sub/2) << shift, 1 << shift
+}
+
+// record counts a duration, and tells if the buckets had to grow.
+func (h *histogram) record(d time.Duration) (grew bool) {
+	if d < 0 {
+		d = 0
+	}
+	idx := h.index(uint64(d))
+	grew = h.growTo(idx)
+	h.counts[idx]++
+	if h.count == 0 || d < h.min {
+		h.min = d
+	}
+	if d > h.max {
+		h.max = d
+	}
+	h.count++
+	h.sum += float64(d)
+	h.sumSq += float64(d) * float64(d)
+	return grew
+}
+
+// growTo makes room for the bucket at idx, and tells if it had to.
+func (h *histogram) growTo(idx int) bool {
+	if idx < len(h.counts) {
+		return false
+	}
+	n := 2 * len(h.counts)
+	if n <= idx {
+		n = idx + 1
+	}
+	counts := make([]uint64, n)
+	copy(counts, h.counts)
+	h.counts = counts
+	return true
+}
+
+// add counts the durations of a step, whether it holds samples or a
+// histogram of another precision.
+func (h *histogram) add(step *TimeStep) {
+	other := step.hist
+	switch {
+	case other == nil:
+		for _, d := range step.all {
+			h.record(d)
+		}
+		return
+	case other.subBits == h.subBits:
+		h.merge(other)
+		return
+	}
+	// move the counts to the buckets of h holding the middle of theirs,
+	// but keep the exact moments
+	moved := &histogram{
+		subBits: h.subBits,
+		count:   other.count,
+		min:     other.min,
+		max:     other.max,
+		sum:     other.sum,
+		sumSq:   other.sumSq,
+	}
+	for i, c := range other.counts {
+		if c == 0 {
+			continue
+		}
+		lowest, width := other.bucket(i)
+		idx := moved.index(lowest + width/2)
+		moved.growTo(idx)
+		moved.counts[idx] += c
+	}
+	h.merge(moved)
+}
+
+func (h *histogram) merge(other *histogram) {
+	if other.count == 0 {
+		return
+	}
+	if len(other.counts) > len(h.counts) {
+		counts := make([]uint64, len(other.counts))
+		copy(counts, h.counts)
+		h.counts = counts
+	}
+	for i, c := range other.counts {
+		h.counts[i] += c
+	}
+	if h.count == 0 || other.min < h.min {
+		h.min = other.min
+	}
+	if other.max > h.max {
+		h.max = other.max
+	}
+	h.count += other.count
+	h.sum += other.sum
+	h.sumSq += other.sumSq
+}
+
+// quantile returns the middle of the bucket holding the q-th quantile.
+func (h *histogram) quantile(q float64) time.Duration {
+	switch {
+	case h.count == 0:
+		return 0
+	case q <= 0:
+		return h.min
+	case q >= 1:
+		return h.max
+	}
+	rank := uint64(math.Ceil(q * float64(h.count)))
+	var seen uint64
+	for idx, c := range h.counts {
+		seen += c
+		if seen < rank {
+			continue
+		}
+		lowest, width := h.bucket(idx)
+		mid := time.Duration(lowest + width/2)
+		if mid < h.min {
+			return h.min
+		}
+		if mid > h.max {
+			return h.max
+		}
+		return mid
+	}
+	return h.max
+}
+
+// newHistStep computes the statistics of a step from a histogram. No
+// duration is left out of them.
+func newHistStep(h *histogram) TimeStep {
+	step := TimeStep{hist: h}
+	if h.count == 0 {
+		return step
+	}
+	n := float64(h.count)
+	mean := h.sum / n
+	step.Min = h.min
+	step.Max = h.max
+	step.Avg = time.Duration(mean)
+	step.SD = time.Duration(math.Sqrt(math.Max(0, h.sumSq/n-mean*mean)))
+	return step
+}
+
+type histBenchKit struct {
+	n        int
+	sigfigs  int
+	grow     bool
+	setup    time.Time
+	start    time.Time
+	teardown time.Time
+
+	shards *shards[*histEach]
+
+	results *TimeResult
+}
+
+func (h *histBenchKit) Setup()    { h.setup = time.Now() }
+func (h *histBenchKit) Starting() { h.start = time.Now() }
+
+func (h *histBenchKit) Each() BenchEach { return h.shards.next() }
+
+func (h *histBenchKit) Teardown() {
+	h.teardown = time.Now()
+
+	shards := h.shards.list()
+	steps := h.n
+	if h.grow {
+		steps = 0
+	}
+	reallocs := 0
+	for _, shard := range shards {
+		if shard.steps > steps {
+			steps = shard.steps
+		}
+		reallocs += shard.reallocs
+	}
+	merged := make([]*histogram, steps)
+	for i := range merged {
+		merged[i] = newHistogram(h.sigfigs)
+		for _, shard := range shards {
+			if i < shard.steps {
+				merged[i].merge(shard.hists[i])
+			}
+		}
+	}
+
+	h.results.N = steps
+	h.results.Setup = h.setup
+	h.results.Start = h.start
+	h.results.Teardown = h.teardown
+	h.results.Reallocs = reallocs
+	h.results.Each = make([]TimeStep, steps)
+	for i, hist := range merged {
+		h.results.Each[i] = newHistStep(hist)
+	}
+}
+
+type histEach struct {
+	sigfigs  int
+	grow     bool
+	reallocs int
+	steps    int
+	before   []time.Time
+	hists    []*histogram
+}
+
+func newHistEach(n, sigfigs int, grow bool) *histEach {
+	each := &histEach{
+		sigfigs: sigfigs,
+		grow:    grow,
+		steps:   n,
+		before:  make([]time.Time, n),
+		hists:   make([]*histogram, n),
+	}
+	for i := range each.hists {
+		each.hists[i] = newHistogram(sigfigs)
+	}
+	if grow {
+		each.steps = 0
+	}
+	return each
+}
+
+// growTo makes room for step id, if the kit grows.
+func (h *histEach) growTo(id int) {
+	if !h.grow {
+		return
+	}
+	if id >= h.steps {
+		h.steps = id + 1
+	}
+	if id < len(h.hists) {
+		return
+	}
+	n := 2 * len(h.hists)
+	if n <= id {
+		n = id + 1
+	}
+	before := make([]time.Time, n)
+	copy(before, h.before)
+	hists := make([]*histogram, n)
+	copy(hists, h.hists)
+	for i := len(h.hists); i < n; i++ {
+		hists[i] = newHistogram(h.sigfigs)
+	}
+	h.before, h.hists = before, hists
+	h.reallocs++
+}
+
+func (h *histEach) record(id int, dur time.Duration) {
+	h.growTo(id)
+	if h.hists[id].record(dur) {
+		h.reallocs++
+	}
+}
+
+func (h *histEach) Before(id int) {
+	h.growTo(id)
+	h.before[id] = time.Now()
+}
+
+func (h *histEach) After(id int) {
+	end := time.Now()
+	h.growTo(id)
+	if h.before[id].IsZero() {
+		panic("benchkit: After called without a matching Before on the same BenchEach")
+	}
+	h.record(id, end.Sub(h.before[id]))
+}
+
+func (h *histEach) Begin(id int) Token {
+	return Token{id: id, start: time.Now()}
+}
+
+func (h *histEach) End(tok Token) {
+	h.record(tok.id, time.Since(tok.start))
+}
+
+// HistTime will track timings over exactly n steps, like Time, but counts
+// the durations of each step in a histogram instead of keeping them all.
+// Its memory is bounded no matter how many times the steps run, which
+// suits long runs.
+//
+// Durations are counted with sigfigs significant decimal digits, from 1
+// to 5: with 3, a duration is known within 0.1% of its value. The more
+// digits, the more memory a histogram takes.
+//
+// The steps of the result answer Count, Quantile, Min, Max, Avg and SD,
+// but have no Samples, and none of their durations are filtered out. The
+// Grow option is supported.
+func HistTime(n, sigfigs int, opts ...Option) (BenchKit, *TimeResult) {
+	o := newOptions(opts)
+	bench := &histBenchKit{
+		n:       n,
+		sigfigs: sigfigs,
+		grow:    o.grow,
+		results: &TimeResult{},
+		shards: newShards(func() *histEach {
+			return newHistEach(n, sigfigs, o.grow)
+		}),
+	}
+	return bench, bench.results
+}
@@ -0,0 +1,14 @@
+package stats
+
+import "math"
+
+// NormCDF is the cumulative distribution function of the standard normal
+// distribution.
+func NormCDF(x float64) float64 {
+	return 0.5 * math.Erfc(-x/math.Sqrt2)
+}
+
+// NormQuantile is the inverse of NormCDF.
+func NormQuantile(p float64) float64 {
+	return math.Sqrt2 * math.Erfinv(2*p-1)
+}
@@ -0,0 +1,42 @@
+package stats
+
+import "math"
+
+// StudentCDF is the cumulative distribution function of Student's t
+// distribution with df degrees of freedom.
+func StudentCDF(t, df float64) float64 {
+	if math.IsInf(df, 1) {
+		return NormCDF(t)
+	}
+	tail := 0.5 * RegIncBeta(df/(df+t*t), df/2, 0.5)
+	if t > 0 {
+		return 1 - tail
+	}
+	return tail
+}
+
+// StudentQuantile is the inverse of StudentCDF, found by bisection.
+func StudentQuantile(p, df float64) float64 {
+	switch {
+	case p <= 0:
+		return math.Inf(-1)
+	case p >= 1:
+		return math.Inf(1)
+	}
+	lo, hi := -1.0, 1.0
+	for StudentCDF(lo, df) > p {
+		lo *= 2
+	}
+	for StudentCDF(hi, df) < p {
+		hi *= 2
+	}
+	for i := 0; i < 100 && hi-lo > 1e-12; i++ {
+		mid := (lo + hi) / 2
+		if StudentCDF(mid, df) < p {
+			lo = mid
+		} else {
+			hi = mid
+		}
+	}
+	return (lo + hi) / 2
+}
@@ -0,0 +1,484 @@
+package benchkit
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"runtime"
+	"time"
+)
+
+// SchemaVersion is the version of the JSON schema of the results. Results
+// are marshaled with it, and only results of this version are unmarshaled.
+//
+// A TimeResult is marshaled as:
+//
+//	{
+//	  "version": 1,
+//	  "kind": "time",
+//	  "n": 2,
+//	  "setup": "2006-01-02T15:04:05.999999999Z", "start": "...", "teardown": "...",
+//	  "names": ["parse", "encode"],
+//	  "reallocs": 0,
+//	  "steps": [
+//	    {"filter": {"name": "quantile", "from": 0.5, "to": 0.95}, "samples_ns": [1200, 1300]},
+//	    {"histogram": {"sub_bits": 11, "buckets": [[1200, 1], [1300, 1]], "count": 2,
+//	                   "min_ns": 1200, "max_ns": 1300, "sum": 2500, "sum_sq": 3130000}}
+//	  ]
+//	}
+//
+// The samples of a step are its durations in nanoseconds, in increasing
+// order, and the buckets of a histogram are pairs of index and count. The
+// filter is one of "none", "quantile" (from, to), "tukey" (k) or "mad"
+// (k). Steps filtered by other filters save the window of their significant
+// samples instead, as in {"window": {"lo": 1, "hi": 2, "low": "too fast",
+// "high": ""}, "samples_ns": [...]}. A MemResult is marshaled as:
+//
+//	{
+//	  "version": 1,
+//	  "kind": "memory",
+//	  "n": 2,
+//	  "setup": {"alloc": 1024, "total_alloc": 2048, ...},
+//	  "start": {...}, "teardown": {...},
+//	  "before_each": [{...}, {...}],
+//	  "after_each": [{...}, {...}],
+//	  "total_each": [{"runs": 100, "bytes": 409600, "allocs": 300}, {...}],
+//	  "names": ["parse", "encode"],
+//	  "reallocs": 0
+//	}
+//
+// where the MemStats keep their scalar fields, but not PauseNs, PauseEnd
+// and BySize.
+const SchemaVersion = 1
+
+const (
+	timeKind   = "time"
+	memoryKind = "memory"
+)
+
+type header struct {
+	Version int    `json:"version"`
+	Kind    string `json:"kind"`
+}
+
+// checkHeader makes sure the data holds results of the kind, before
+// they're decoded.
+func checkHeader(data []byte, kind string) error {
+	var h header
+	if err := json.Unmarshal(data, &h); err != nil {
+		return err
+	}
+	if h.Version != SchemaVersion {
+		return fmt.Errorf("benchkit: unsupported schema version %d, want %d", h.Version, SchemaVersion)
+	}
+	if h.Kind != kind {
+		return fmt.Errorf("benchkit: got %q results, want %q", h.Kind, kind)
+	}
+	return nil
+}
+
+type timeResultJSON struct {
+	header
+	N        int            `json:"n"`
+	Setup    time.Time      `json:"setup"`
+	Start    time.Time      `json:"start"`
+	Teardown time.Time      `json:"teardown"`
+	Names    []string       `json:"names,omitempty"`
+	Reallocs int            `json:"reallocs"`
+	Steps    []timeStepJSON `json:"steps"`
+}
+
+type timeStepJSON struct {
+	Filter    *filterJSON    `json:"filter,omitempty"`
+	Window    *windowJSON    `json:"window,omitempty"`
+	Samples   []int64        `json:"samples_ns,omitempty"`
+	Histogram *histogramJSON `json:"histogram,omitempty"`
+}
+
+type filterJSON struct {
+	Name string  `json:"name"`
+	From float64 `json:"from,omitempty"`
+	To   float64 `json:"to,omitempty"`
+	K    float64 `json:"k,omitempty"`
+}
+
+type windowJSON struct {
+	Lo   int    `json:"lo"`
+	Hi   int    `json:"hi"`
+	Low  string `json:"low"`
+	High string `json:"high"`
+}
+
+type histogramJSON struct {
+	SubBits uint        `json:"sub_bits"`
+	Buckets [][2]uint64 `json:"buckets"`
+	Count   uint64      `json:"count"`
+	Min     int64       `json:"min_ns"`
+	Max     int64       `json:"max_ns"`
+	Sum     float64     `json:"sum"`
+	SumSq   float64     `json:"sum_sq"`
+}
+
+// MarshalJSON encodes the result with the schema of SchemaVersion. Steps
+// filtered by a Filter of another package keep their significant durations
+// and outliers, but are unmarshaled without that filter.
+func (t TimeResult) MarshalJSON() ([]byte, error) {
+	out := timeResultJSON{
+		header:   header{Version: SchemaVersion, Kind: timeKind},
+		N:        t.N,
+		Setup:    t.Setup,
+		Start:    t.Start,
+		Teardown: t.Teardown,
+		Names:    t.Names,
+		Reallocs: t.Reallocs,
+		Steps:    make([]timeStepJSON, len(t.Each)),
+	}
+	for i := range t.Each {
+		step, err := marshalTimeStep(&t.Each[i])
+		if err != nil {
+			return nil, fmt.Errorf("benchkit: step %d: %v", i, err)
+		}
+		out.Steps[i] = step
+	}
+	return json.Marshal(out)
+}
+
+// UnmarshalJSON decodes a result encoded by MarshalJSON, recomputing the
+// statistics of its steps.
+func (t *TimeResult) UnmarshalJSON(data []byte) error {
+	if err := checkHeader(data, timeKind); err != nil {
+		return err
+	}
+	var in timeResultJSON
+	if err := json.Unmarshal(data, &in); err != nil {
+		return err
+	}
+	*t = TimeResult{
+		N:        in.N,
+		Setup:    in.Setup,
+		Start:    in.Start,
+		Teardown: in.Teardown,
+		Names:    in.Names,
+		Reallocs: in.Reallocs,
+		Each:     make([]TimeStep, len(in.Steps)),
+	}
+	for i, step := range in.Steps {
+		var err error
+		if t.Each[i], err = unmarshalTimeStep(step); err != nil {
+			return fmt.Errorf("benchkit: step %d: %v", i, err)
+		}
+	}
+	return nil
+}
+
+func marshalTimeStep(step *TimeStep) (timeStepJSON, error) {
+	if h := step.hist; h != nil {
+		out := &histogramJSON{
+			SubBits: h.subBits,
+			Buckets: [][2]uint64{},
+			Count:   h.count,
+			Min:     int64(h.min),
+			Max:     int64(h.max),
+			Sum:     h.sum,
+			SumSq:   h.sumSq,
+		}
+		for idx, c := range h.counts {
+			if c != 0 {
+				out.Buckets = append(out.Buckets, [2]uint64{uint64(idx), c})
+			}
+		}
+		return timeStepJSON{Histogram: out}, nil
+	}
+	out := timeStepJSON{Samples: make([]int64, len(step.all))}
+	for i, dur := range step.all {
+		out.Samples[i] = int64(dur)
+	}
+	if step.filter == nil {
+		return out, nil
+	}
+	if out.Filter = marshalFilter(step.filter); out.Filter == nil {
+		out.Window = &windowJSON{
+			Lo:   len(step.Low.Durations),
+			Hi:   len(step.all) - len(step.High.Durations),
+			Low:  step.Low.Reason,
+			High: step.High.Reason,
+		}
+	}
+	return out, nil
+}
+
+func unmarshalTimeStep(in timeStepJSON) (TimeStep, error) {
+	if h := in.Histogram; h != nil {
+		if !validSubBits(h.SubBits) {
+			return TimeStep{}, fmt.Errorf("invalid histogram sub_bits %d", h.SubBits)
+		}
+		hist := &histogram{
+			subBits: h.SubBits,
+			counts:  make([]uint64, 1<<h.SubBits),
+			count:   h.Count,
+			min:     time.Duration(h.Min),
+			max:     time.Duration(h.Max),
+			sum:     h.Sum,
+			sumSq:   h.SumSq,
+		}
+		last := uint64(hist.index(math.MaxInt64))
+		for _, bucket := range h.Buckets {
+			if bucket[0] > last {
+				return TimeStep{}, fmt.Errorf("histogram bucket %d out of range", bucket[0])
+			}
+			idx := int(bucket[0])
+			for idx >= len(hist.counts) {
+				hist.counts = append(hist.counts, make([]uint64, len(hist.counts))...)
+			}
+			hist.counts[idx] = bucket[1]
+		}
+		return newHistStep(hist), nil
+	}
+	durs := make([]time.Duration, len(in.Samples))
+	for i, ns := range in.Samples {
+		durs[i] = time.Duration(ns)
+	}
+	if w := in.Window; w != nil && in.Filter == nil {
+		if w.Lo < 0 || w.Lo > w.Hi || w.Hi > len(durs) {
+			return TimeStep{}, fmt.Errorf("window [%d, %d] out of range of %d samples", w.Lo, w.Hi, len(durs))
+		}
+		return NewTimeStep(durs, savedWindow(*w)), nil
+	}
+	filter, err := unmarshalFilter(in.Filter)
+	if err != nil {
+		return TimeStep{}, err
+	}
+	return NewTimeStep(durs, filter), nil
+}
+
+// validSubBits tells if a histogram of newHistogram can have subBits.
+func validSubBits(subBits uint) bool {
+	for sigfigs := 1; sigfigs <= 5; sigfigs++ {
+		if newHistogram(sigfigs).subBits == subBits {
+			return true
+		}
+	}
+	return false
+}
+
+// savedWindow is the window of a step whose filter couldn't be marshaled.
+// It only fits the durations of that step.
+type savedWindow windowJSON
+
+func (w savedWindow) Window(sorted []time.Duration) (int, int, string, string) {
+	return w.Lo, w.Hi, w.Low, w.High
+}
+
+// marshalFilter returns nil for the filters of other packages.
+func marshalFilter(f Filter) *filterJSON {
+	switch f := f.(type) {
+	case noFilter:
+		return &filterJSON{Name: "none"}
+	case quantileFilter:
+		return &filterJSON{Name: "quantile", From: f.from, To: f.to}
+	case tukeyFilter:
+		return &filterJSON{Name: "tukey", K: f.k}
+	case madFilter:
+		return &filterJSON{Name: "mad", K: f.k}
+	}
+	return nil
+}
+
+func unmarshalFilter(in *filterJSON) (Filter, error) {
+	if in == nil {
+		return DefaultFilter, nil
+	}
+	switch in.Name {
+	case "none":
+		return NoFilter, nil
+	case "quantile":
+		return QuantileFilter(in.From, in.To), nil
+	case "tukey":
+		return TukeyFilter(in.K), nil
+	case "mad":
+		return MADFilter(in.K), nil
+	}
+	return nil, fmt.Errorf("unknown filter %q", in.Name)
+}
+
+type memResultJSON struct {
+	header
+	N          int             `json:"n"`
+	Setup      *memStatsJSON   `json:"setup"`
+	Start      *memStatsJSON   `json:"start"`
+	Teardown   *memStatsJSON   `json:"teardown"`
+	BeforeEach []*memStatsJSON `json:"before_each"`
+	AfterEach  []*memStatsJSON `json:"after_each"`
+	TotalEach  []memTotalJSON  `json:"total_each,omitempty"`
+	Names      []string        `json:"names,omitempty"`
+	Reallocs   int             `json:"reallocs"`
+}
+
+type memTotalJSON struct {
+	Runs   int    `json:"runs"`
+	Bytes  uint64 `json:"bytes"`
+	Allocs uint64 `json:"allocs"`
+}
+
+// memStatsJSON holds the scalar fields of runtime.MemStats.
+type memStatsJSON struct {
+	Alloc         uint64  `json:"alloc"`
+	TotalAlloc    uint64  `json:"total_alloc"`
+	Sys           uint64  `json:"sys"`
+	Lookups       uint64  `json:"lookups"`
+	Mallocs       uint64  `json:"mallocs"`
+	Frees         uint64  `json:"frees"`
+	HeapAlloc     uint64  `json:"heap_alloc"`
+	HeapSys       uint64  `json:"heap_sys"`
+	HeapIdle      uint64  `json:"heap_idle"`
+	HeapInuse     uint64  `json:"heap_inuse"`
+	HeapReleased  uint64  `json:"heap_released"`
+	HeapObjects   uint64  `json:"heap_objects"`
+	StackInuse    uint64  `json:"stack_inuse"`
+	StackSys      uint64  `json:"stack_sys"`
+	MSpanInuse    uint64  `json:"mspan_inuse"`
+	MSpanSys      uint64  `json:"mspan_sys"`
+	MCacheInuse   uint64  `json:"mcache_inuse"`
+	MCacheSys     uint64  `json:"mcache_sys"`
+	BuckHashSys   uint64  `json:"buck_hash_sys"`
+	GCSys         uint64  `json:"gc_sys"`
+	OtherSys      uint64  `json:"other_sys"`
+	NextGC        uint64  `json:"next_gc"`
+	LastGC        uint64  `json:"last_gc"`
+	PauseTotalNs  uint64  `json:"pause_total_ns"`
+	NumGC         uint32  `json:"num_gc"`
+	NumForcedGC   uint32  `json:"num_forced_gc"`
+	GCCPUFraction float64 `json:"gc_cpu_fraction"`
+	EnableGC      bool    `json:"enable_gc"`
+	DebugGC       bool    `json:"debug_gc"`
+}
+
+func marshalMemStats(m *runtime.MemStats) *memStatsJSON {
+	if m == nil {
+		return nil
+	}
+	return &memStatsJSON{
+		Alloc:         m.Alloc,
+		TotalAlloc:    m.TotalAlloc,
+		Sys:           m.Sys,
+		Lookups:       m.Lookups,
+		Mallocs:       m.Mallocs,
+		Frees:         m.Frees,
+		HeapAlloc:     m.HeapAlloc,
+		HeapSys:       m.HeapSys,
+		HeapIdle:      m.HeapIdle,
+		HeapInuse:     m.HeapInuse,
+		HeapReleased:  m.HeapReleased,
+		HeapObjects:   m.HeapObjects,
+		StackInuse:    m.StackInuse,
+		StackSys:      m.StackSys,
+		MSpanInuse:    m.MSpanInuse,
+		MSpanSys:      m.MSpanSys,
+		MCacheInuse:   m.MCacheInuse,
+		MCacheSys:     m.MCacheSys,
+		BuckHashSys:   m.BuckHashSys,
+		GCSys:         m.GCSys,
+		OtherSys:      m.OtherSys,
+		NextGC:        m.NextGC,
+		LastGC:        m.LastGC,
+		PauseTotalNs:  m.PauseTotalNs,
+		NumGC:         m.NumGC,
+		NumForcedGC:   m.NumForcedGC,
+		GCCPUFraction: m.GCCPUFraction,
+		EnableGC:      m.EnableGC,
+		DebugGC:       m.DebugGC,
+	}
+}
+
+func (m *memStatsJSON) memStats() *runtime.MemStats {
+	if m == nil {
+		return nil
+	}
+	return &runtime.MemStats{
+		Alloc:         m.Alloc,
+		TotalAlloc:    m.TotalAlloc,
+		Sys:           m.Sys,
+		Lookups:       m.Lookups,
+		Mallocs:       m.Mallocs,
+		Frees:         m.Frees,
+		HeapAlloc:     m.HeapAlloc,
+		HeapSys:       m.HeapSys,
+		HeapIdle:      m.HeapIdle,
+		HeapInuse:     m.HeapInuse,
+		HeapReleased:  m.HeapReleased,
+		HeapObjects:   m.HeapObjects,
+		StackInuse:    m.StackInuse,
+		StackSys:      m.StackSys,
+		MSpanInuse:    m.MSpanInuse,
+		MSpanSys:      m.MSpanSys,
+		MCacheInuse:   m.MCacheInuse,
+		MCacheSys:     m.MCacheSys,
+		BuckHashSys:   m.BuckHashSys,
+		GCSys:         m.GCSys,
+		OtherSys:      m.OtherSys,
+		NextGC:        m.NextGC,
+		LastGC:        m.LastGC,
+		PauseTotalNs:  m.PauseTotalNs,
+		NumGC:         m.NumGC,
+		NumForcedGC:   m.NumForcedGC,
+		GCCPUFraction: m.GCCPUFraction,
+		EnableGC:      m.EnableGC,
+		DebugGC:       m.DebugGC,
+	}
+}
+
+// MarshalJSON encodes the result with the schema of SchemaVersion.
+func (m MemResult) MarshalJSON() ([]byte, error) {
+	out := memResultJSON{
+		header:     header{Version: SchemaVersion, Kind: memoryKind},
+		N:          m.N,
+		Setup:      marshalMemStats(m.Setup),
+		Start:      marshalMemStats(m.Start),
+		Teardown:   marshalMemStats(m.Teardown),
+		BeforeEach: make([]*memStatsJSON, len(m.BeforeEach)),
+		AfterEach:  make([]*memStatsJSON, len(m.AfterEach)),
+		Names:      m.Names,
+		Reallocs:   m.Reallocs,
+	}
+	for i, before := range m.BeforeEach {
+		out.BeforeEach[i] = marshalMemStats(before)
+	}
+	for i, after := range m.AfterEach {
+		out.AfterEach[i] = marshalMemStats(after)
+	}
+	for _, total := range m.TotalEach {
+		out.TotalEach = append(out.TotalEach, memTotalJSON(total))
+	}
+	return json.Marshal(out)
+}
+
+// UnmarshalJSON decodes a result encoded by MarshalJSON.
+func (m *MemResult) UnmarshalJSON(data []byte) error {
+	if err := checkHeader(data, memoryKind); err != nil {
+		return err
+	}
+	var in memResultJSON
+	if err := json.Unmarshal(data, &in); err != nil {
+		return err
+	}
+	*m = MemResult{
+		N:          in.N,
+		Setup:      in.Setup.memStats(),
+		Start:      in.Start.memStats(),
+		Teardown:   in.Teardown.memStats(),
+		BeforeEach: make([]*runtime.MemStats, len(in.BeforeEach)),
+		AfterEach:  make([]*runtime.MemStats, len(in.AfterEach)),
+		Names:      in.Names,
+		Reallocs:   in.Reallocs,
+	}
+	for i, before := range in.BeforeEach {
+		m.BeforeEach[i] = before.memStats()
+	}
+	for i, after := range in.AfterEach {
+		m.AfterEach[i] = after.memStats()
+	}
+	for _, total := range in.TotalEach {
+		m.TotalEach = append(m.TotalEach, MemTotal(total))
+	}
+	return nil
+}
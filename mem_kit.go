@@ -2,6 +2,7 @@ package benchkit
 
 import (
 	"runtime"
+	"sync"
 )
 
 // MemResult contains the memory measurements of a memory benchmark
@@ -13,10 +14,39 @@ type MemResult struct {
 	Teardown   *runtime.MemStats
 	BeforeEach []*runtime.MemStats
 	AfterEach  []*runtime.MemStats
+	// TotalEach sums the memory allocated by each step over all the times
+	// it was measured, while BeforeEach and AfterEach only hold the last.
+	TotalEach []MemTotal
+	// Names of the steps, if they were named.
+	Names []string
+	// Reallocs counts the times memory was allocated during the benchmark,
+	// because more steps were seen than allocated in advance.
+	Reallocs int
+}
+
+// MemTotal is the memory allocated by a step over all its runs.
+type MemTotal struct {
+	// Runs is the number of times the step was measured.
+	Runs int
+	// Bytes is the sum of the TotalAlloc added by each run.
+	Bytes uint64
+	// Allocs is the sum of the Mallocs added by each run.
+	Allocs uint64
+}
+
+// Index returns the position of the named step in BeforeEach and
+// AfterEach, or -1.
+func (m *MemResult) Index(name string) int {
+	return indexOf(m.Names, name)
+}
+
+// StepName returns the name of the i-th step, or its index if the steps
+// aren't named.
+func (m *MemResult) StepName(i int) string {
+	return stepName(m.Names, i)
 }
 
 type memBenchKit struct {
-	n        int
 	setup    *runtime.MemStats
 	start    *runtime.MemStats
 	teardown *runtime.MemStats
@@ -30,12 +60,14 @@ func (m *memBenchKit) Starting()       { runtime.ReadMemStats(m.start) }
 func (m *memBenchKit) Each() BenchEach { return m.each }
 func (m *memBenchKit) Teardown() {
 	runtime.ReadMemStats(m.teardown)
-	m.results.N = m.n
+	m.results.N = m.each.steps
+	m.results.Reallocs = m.each.reallocs
 	m.results.Setup = m.setup
 	m.results.Start = m.start
 	m.results.Teardown = m.teardown
-	m.results.BeforeEach = m.each.beforeEach
-	m.results.AfterEach = m.each.afterEach
+	m.results.BeforeEach = m.each.beforeEach[:m.each.steps]
+	m.results.AfterEach = m.each.afterEach[:m.each.steps]
+	m.results.TotalEach = m.each.totalEach[:m.each.steps]
 
 	sub(m.start, m.setup, m.start)
 	sub(m.teardown, m.setup, m.teardown)
@@ -48,25 +80,90 @@ func (m *memBenchKit) Teardown() {
 	}
 }
 
+// memEach is shared by all callers of Each. Memory stats are global
+// to the process anyways, so there's nothing to gain in sharding them.
 type memEach struct {
+	mu         sync.Mutex
+	grow       bool
+	reallocs   int
+	steps      int
 	beforeEach []*runtime.MemStats
 	afterEach  []*runtime.MemStats
+	totalEach  []MemTotal
 }
 
-func (m *memEach) Before(id int) { runtime.ReadMemStats(m.beforeEach[id]) }
-func (m *memEach) After(id int)  { runtime.ReadMemStats(m.afterEach[id]) }
+// growTo makes room for step id, if the kit grows.
+func (m *memEach) growTo(id int) {
+	if !m.grow {
+		return
+	}
+	if id >= m.steps {
+		m.steps = id + 1
+	}
+	if id < len(m.beforeEach) {
+		return
+	}
+	n := 2 * len(m.beforeEach)
+	if n <= id {
+		n = id + 1
+	}
+	before := make([]*runtime.MemStats, n)
+	copy(before, m.beforeEach)
+	after := make([]*runtime.MemStats, n)
+	copy(after, m.afterEach)
+	total := make([]MemTotal, n)
+	copy(total, m.totalEach)
+	for i := len(m.beforeEach); i < n; i++ {
+		before[i] = &runtime.MemStats{}
+		after[i] = &runtime.MemStats{}
+	}
+	m.beforeEach, m.afterEach, m.totalEach = before, after, total
+	m.reallocs++
+}
 
-// Memory will track memory allocations using `runtime.ReadMemStats`.
-func Memory(n int) (BenchKit, *MemResult) {
+func (m *memEach) Before(id int) {
+	m.mu.Lock()
+	m.growTo(id)
+	runtime.ReadMemStats(m.beforeEach[id])
+	m.mu.Unlock()
+}
 
+func (m *memEach) After(id int) {
+	m.mu.Lock()
+	m.growTo(id)
+	before, after := m.beforeEach[id], m.afterEach[id]
+	runtime.ReadMemStats(after)
+	total := &m.totalEach[id]
+	total.Runs++
+	total.Bytes += after.TotalAlloc - before.TotalAlloc
+	total.Allocs += after.Mallocs - before.Mallocs
+	m.mu.Unlock()
+}
+
+// Begin and End are equivalent to Before and After; there's only one
+// measurement per step, so the last unit of work to end wins.
+func (m *memEach) Begin(id int) Token { m.Before(id); return Token{id: id} }
+func (m *memEach) End(tok Token)      { m.After(tok.id) }
+
+// Memory will track memory allocations using `runtime.ReadMemStats`.
+//
+// With the Grow option, more than n steps can be tracked, at the cost of
+// reallocations when a step beyond the ones allocated in advance is seen.
+// The number of reallocations is found in the Reallocs of the result.
+//
+// The kit is safe for concurrent use. However, since memory statistics are
+// global to the process, concurrent steps will see each other's allocations.
+func Memory(n int, opts ...Option) (BenchKit, *MemResult) {
+	o := newOptions(opts)
 	bench := &memBenchKit{
-		n:        n,
 		setup:    &runtime.MemStats{},
 		start:    &runtime.MemStats{},
 		teardown: &runtime.MemStats{},
 		each: &memEach{
+			grow:       o.grow,
 			beforeEach: make([]*runtime.MemStats, n),
 			afterEach:  make([]*runtime.MemStats, n),
+			totalEach:  make([]MemTotal, n),
 		},
 		results: &MemResult{},
 	}
@@ -75,6 +172,9 @@ func Memory(n int) (BenchKit, *MemResult) {
 		bench.each.beforeEach[i] = &runtime.MemStats{}
 		bench.each.afterEach[i] = &runtime.MemStats{}
 	}
+	if !o.grow {
+		bench.each.steps = n
+	}
 
 	return bench, bench.results
 }
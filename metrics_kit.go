@@ -0,0 +1,177 @@
+package benchkit
+
+import (
+	"fmt"
+	"math"
+	"runtime/metrics"
+	"sync"
+)
+
+// DefaultMetrics are the names of the metrics sampled by the Metrics kit
+// when none are given. See package runtime/metrics for their meaning.
+var DefaultMetrics = []string{
+	"/gc/heap/allocs:bytes",
+	"/gc/heap/allocs:objects",
+	"/memory/classes/heap/objects:bytes",
+	"/gc/cycles/total:gc-cycles",
+	"/memory/classes/heap/stacks:bytes",
+	"/sched/goroutines:goroutines",
+}
+
+// MetricsResult contains the runtime/metrics measurements of a metrics
+// benchmark at each point of the benchmark. Each measurement holds a value
+// for each metric, in the order of Names. Like in a MemResult, all the
+// measurements but Setup are relative to Setup.
+type MetricsResult struct {
+	N          int
+	Names      []string
+	Setup      []float64
+	Start      []float64
+	Teardown   []float64
+	BeforeEach [][]float64
+	AfterEach  [][]float64
+}
+
+// Index returns the position of the named metric in each measurement,
+// or -1 if it wasn't sampled.
+func (m *MetricsResult) Index(name string) int {
+	return indexOf(m.Names, name)
+}
+
+type metricsBenchKit struct {
+	n        int
+	names    []string
+	setup    []metrics.Sample
+	start    []metrics.Sample
+	teardown []metrics.Sample
+	each     *metricsEach
+
+	results *MetricsResult
+}
+
+func (m *metricsBenchKit) Setup()          { metrics.Read(m.setup) }
+func (m *metricsBenchKit) Starting()       { metrics.Read(m.start) }
+func (m *metricsBenchKit) Each() BenchEach { return m.each }
+func (m *metricsBenchKit) Teardown() {
+	metrics.Read(m.teardown)
+	setup := metricValues(m.setup)
+
+	m.results.N = m.n
+	m.results.Names = m.names
+	m.results.Setup = setup
+	m.results.Start = subValues(metricValues(m.start), setup)
+	m.results.Teardown = subValues(metricValues(m.teardown), setup)
+	m.results.BeforeEach = make([][]float64, m.n)
+	m.results.AfterEach = make([][]float64, m.n)
+	for i := 0; i < m.n; i++ {
+		m.results.BeforeEach[i] = subValues(metricValues(m.each.beforeEach[i]), setup)
+		m.results.AfterEach[i] = subValues(metricValues(m.each.afterEach[i]), setup)
+	}
+}
+
+// metricsEach is shared by all callers of Each, for the same reasons
+// as memEach.
+type metricsEach struct {
+	mu         sync.Mutex
+	beforeEach [][]metrics.Sample
+	afterEach  [][]metrics.Sample
+}
+
+func (m *metricsEach) Before(id int) {
+	m.mu.Lock()
+	metrics.Read(m.beforeEach[id])
+	m.mu.Unlock()
+}
+
+func (m *metricsEach) After(id int) {
+	m.mu.Lock()
+	metrics.Read(m.afterEach[id])
+	m.mu.Unlock()
+}
+
+// Begin and End are equivalent to Before and After; there's only one
+// measurement per step, so the last unit of work to end wins.
+func (m *metricsEach) Begin(id int) Token { m.Before(id); return Token{id: id} }
+func (m *metricsEach) End(tok Token)      { m.After(tok.id) }
+
+// Metrics will track the named metrics using `runtime/metrics`, or the
+// DefaultMetrics if no names are given. Unlike `runtime.ReadMemStats`,
+// reading metrics doesn't stop the world, so it disturbs the benchmark
+// much less than the Memory kit.
+//
+// Only metrics holding a single value are supported: Metrics panics if a
+// name is unknown to the runtime or is a histogram.
+func Metrics(n int, names ...string) (BenchKit, *MetricsResult) {
+	if len(names) == 0 {
+		names = DefaultMetrics
+	}
+	checkMetrics(names)
+
+	bench := &metricsBenchKit{
+		n:        n,
+		names:    names,
+		setup:    newSamples(names),
+		start:    newSamples(names),
+		teardown: newSamples(names),
+		each: &metricsEach{
+			beforeEach: make([][]metrics.Sample, n),
+			afterEach:  make([][]metrics.Sample, n),
+		},
+		results: &MetricsResult{},
+	}
+
+	for i := 0; i < n; i++ {
+		bench.each.beforeEach[i] = newSamples(names)
+		bench.each.afterEach[i] = newSamples(names)
+	}
+
+	return bench, bench.results
+}
+
+func checkMetrics(names []string) {
+	kinds := make(map[string]metrics.ValueKind)
+	for _, desc := range metrics.All() {
+		kinds[desc.Name] = desc.Kind
+	}
+	for _, name := range names {
+		kind, ok := kinds[name]
+		switch {
+		case !ok:
+			panic(fmt.Sprintf("benchkit: unknown metric %q", name))
+		case kind != metrics.KindUint64 && kind != metrics.KindFloat64:
+			panic(fmt.Sprintf("benchkit: metric %q doesn't hold a single value", name))
+		}
+	}
+}
+
+func newSamples(names []string) []metrics.Sample {
+	samples := make([]metrics.Sample, len(names))
+	for i, name := range names {
+		samples[i].Name = name
+	}
+	return samples
+}
+
+func metricValues(samples []metrics.Sample) []float64 {
+	values := make([]float64, len(samples))
+	for i, sample := range samples {
+		switch sample.Value.Kind() {
+		case metrics.KindUint64:
+			values[i] = float64(sample.Value.Uint64())
+		case metrics.KindFloat64:
+			values[i] = sample.Value.Float64()
+		default:
+			// the sample was never read
+			values[i] = math.NaN()
+		}
+	}
+	return values
+}
+
+// subValues subtracts b from a, in place.
+func subValues(a, b []float64) []float64 {
+	for i := range a {
+		a[i] -= b[i]
+	}
+	return a
+}
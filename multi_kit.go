@@ -0,0 +1,101 @@
+package benchkit
+
+// TimeMemResult contains the results of a TimeMemory benchmark.
+type TimeMemResult struct {
+	Time   *TimeResult
+	Memory *MemResult
+}
+
+type multiBenchKit struct {
+	kits []BenchKit
+}
+
+func (m *multiBenchKit) Setup() {
+	for _, kit := range m.kits {
+		kit.Setup()
+	}
+}
+
+func (m *multiBenchKit) Starting() {
+	for _, kit := range m.kits {
+		kit.Starting()
+	}
+}
+
+// Each gives a BenchTokens if the BenchEach of every kit is one.
+func (m *multiBenchKit) Each() BenchEach {
+	each := make(multiEach, len(m.kits))
+	tokens := true
+	for i, kit := range m.kits {
+		each[i] = kit.Each()
+		_, ok := each[i].(BenchTokens)
+		tokens = tokens && ok
+	}
+	if tokens {
+		return multiTokens{each}
+	}
+	return each
+}
+
+func (m *multiBenchKit) Teardown() {
+	for i := len(m.kits) - 1; i >= 0; i-- {
+		m.kits[i].Teardown()
+	}
+}
+
+type multiEach []BenchEach
+
+func (m multiEach) Before(id int) {
+	for _, each := range m {
+		each.Before(id)
+	}
+}
+
+func (m multiEach) After(id int) {
+	for i := len(m) - 1; i >= 0; i-- {
+		m[i].After(id)
+	}
+}
+
+type multiTokens struct {
+	multiEach
+}
+
+func (m multiTokens) Begin(id int) Token {
+	tok := Token{id: id, inner: make([]Token, len(m.multiEach))}
+	for i, each := range m.multiEach {
+		tok.inner[i] = each.(BenchTokens).Begin(id)
+	}
+	return tok
+}
+
+func (m multiTokens) End(tok Token) {
+	for i := len(m.multiEach) - 1; i >= 0; i-- {
+		m.multiEach[i].(BenchTokens).End(tok.inner[i])
+	}
+}
+
+// Multi combines many kits so that they measure the same run of a benchmark.
+// Results are found in the results of each kit.
+//
+// Setup, Starting and Before are called on the kits in the order they're
+// given, After and Teardown in the reverse order. Thus the last kit is the
+// one that wraps the work the closest: put the kits that are costly to
+// sample first, so that their cost isn't measured by the kits after them.
+//
+// If all the kits support BenchTokens, so does the BenchEach of Multi.
+func Multi(kits ...BenchKit) BenchKit {
+	return &multiBenchKit{kits: kits}
+}
+
+// TimeMemory will track both timings and memory allocations in a single
+// run, as if using Time(n, m, opts...) and Memory(n, opts...). Memory is
+// sampled outside of the timings, so `runtime.ReadMemStats` doesn't count
+// in the durations.
+//
+// The memory of each step is the one of the last time it was measured.
+func TimeMemory(n, m int, opts ...Option) (BenchKit, *TimeMemResult) {
+	memkit, mem := Memory(n, opts...)
+	timekit, times := Time(n, m, opts...)
+	return Multi(memkit, timekit), &TimeMemResult{Time: times, Memory: mem}
+}
@@ -0,0 +1,145 @@
+package benchkit
+
+import (
+	"strconv"
+	"sync"
+)
+
+// NamedKit is like a BenchKit, but its steps are identified by name
+// instead of by id.
+type NamedKit interface {
+	// Setup must be called before doing any benchmark allocation.
+	Setup()
+	// Starting must be called once your benchmark data is ready,
+	// and you're about to start the work you want to benchmark.
+	Starting()
+	// Teardown must be called once your benchmark is done.
+	Teardown()
+	// Each gives an object that tracks each step of your work.
+	Each() NamedEach
+}
+
+// NamedEach tracks metrics about named work units of your benchmark.
+type NamedEach interface {
+	// Before must be called _before_ starting a unit of work.
+	Before(name string)
+	// After must be called _after_ finishing a unit of work.
+	After(name string)
+}
+
+type namedBenchKit struct {
+	kit      BenchKit
+	names    *stepNames
+	teardown func(names []string)
+}
+
+func (n *namedBenchKit) Setup()    { n.kit.Setup() }
+func (n *namedBenchKit) Starting() { n.kit.Starting() }
+func (n *namedBenchKit) Each() NamedEach {
+	return &namedEach{each: n.kit.Each(), names: n.names}
+}
+func (n *namedBenchKit) Teardown() {
+	n.kit.Teardown()
+	n.teardown(n.names.all())
+}
+
+type namedEach struct {
+	each  BenchEach
+	names *stepNames
+}
+
+func (n *namedEach) Before(name string) { n.each.Before(n.names.id(name)) }
+func (n *namedEach) After(name string)  { n.each.After(n.names.id(name)) }
+
+// stepNames gives ids to the names of the steps, in the order in which
+// they're first seen.
+type stepNames struct {
+	mu    sync.RWMutex
+	ids   map[string]int
+	names []string
+}
+
+func newStepNames(n int) *stepNames {
+	return &stepNames{
+		ids:   make(map[string]int, n),
+		names: make([]string, 0, n),
+	}
+}
+
+func (s *stepNames) id(name string) int {
+	s.mu.RLock()
+	id, ok := s.ids[name]
+	s.mu.RUnlock()
+	if ok {
+		return id
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if id, ok := s.ids[name]; ok {
+		return id
+	}
+	id = len(s.names)
+	s.ids[name] = id
+	s.names = append(s.names, name)
+	return id
+}
+
+func (s *stepNames) all() []string {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return append([]string(nil), s.names...)
+}
+
+// NamedTime is like Time, but steps are identified by name. Names are
+// given ids on the fly, in the order in which they're first seen. The kit
+// grows as new names are seen, so n is only a hint of how many steps to
+// allocate in advance.
+//
+// Once the kit is torn down, the results only hold the steps that were
+// named, and their names are found in Names.
+func NamedTime(n, m int) (NamedKit, *TimeResult) {
+	kit, results := Time(n, m, Grow())
+	return &namedBenchKit{
+		kit:   kit,
+		names: newStepNames(n),
+		teardown: func(names []string) {
+			results.N = len(names)
+			results.Names = names
+			results.Each = results.Each[:len(names)]
+		},
+	}, results
+}
+
+// NamedMemory is like Memory, but steps are identified by name, like for
+// NamedTime.
+func NamedMemory(n int) (NamedKit, *MemResult) {
+	kit, results := Memory(n, Grow())
+	return &namedBenchKit{
+		kit:   kit,
+		names: newStepNames(n),
+		teardown: func(names []string) {
+			results.N = len(names)
+			results.Names = names
+			results.BeforeEach = results.BeforeEach[:len(names)]
+			results.AfterEach = results.AfterEach[:len(names)]
+			results.TotalEach = results.TotalEach[:len(names)]
+		},
+	}, results
+}
+
+func indexOf(names []string, name string) int {
+	for i, n := range names {
+		if n == name {
+			return i
+		}
+	}
+	return -1
+}
+
+func stepName(names []string, i int) string {
+	if i < len(names) {
+		return names[i]
+	}
+	return strconv.Itoa(i)
+}
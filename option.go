@@ -0,0 +1,31 @@
+package benchkit
+
+// Option configures a kit when it's created. Kits ignore the options that
+// don't apply to them.
+type Option func(*options)
+
+type options struct {
+	grow   bool
+	filter Filter
+}
+
+func newOptions(opts []Option) options {
+	o := options{filter: DefaultFilter}
+	for _, opt := range opts {
+		opt(&o)
+	}
+	return o
+}
+
+// Grow lets a kit track as many steps as it sees, instead of exactly n.
+// The sizes given to the kit become hints of how much memory to allocate
+// in advance, and the steps beyond them are allocated as they're seen.
+func Grow() Option {
+	return func(o *options) { o.grow = true }
+}
+
+// WithFilter makes a kit select the significant durations of each step
+// with f, instead of the DefaultFilter.
+func WithFilter(f Filter) Option {
+	return func(o *options) { o.filter = f }
+}
@@ -0,0 +1,70 @@
+package benchkit
+
+import (
+	"math"
+	"time"
+
+	"github.com/aybabtme/benchkit/internal/stats"
+)
+
+// QuantileMethod estimates the quantiles of a sample of durations.
+type QuantileMethod int
+
+const (
+	// Linear interpolates between the two durations closest to the
+	// quantile. This is the default of R and NumPy.
+	Linear QuantileMethod = iota
+	// NearestRank picks the smallest duration that is greater or equal to
+	// at least q of the durations.
+	NearestRank
+	// HarrellDavis averages all the durations, weighted by a beta
+	// distribution centered on the quantile. It's more efficient on small
+	// samples than the other methods, but costs O(n) for each quantile.
+	HarrellDavis
+)
+
+// Quantile estimates the q-th quantile of sorted, with 0 <= q <= 1. The
+// durations must be sorted in increasing order.
+func (m QuantileMethod) Quantile(sorted []time.Duration, q float64) time.Duration {
+	if len(sorted) == 0 {
+		return 0
+	}
+	q = math.Max(0, math.Min(1, q))
+	switch m {
+	case NearestRank:
+		idx := int(math.Ceil(q*float64(len(sorted)))) - 1
+		return sorted[clamp(idx, 0, len(sorted)-1)]
+	case HarrellDavis:
+		return harrellDavis(sorted, q)
+	default:
+		return quantile(sorted, q)
+	}
+}
+
+// Quantiles estimates many quantiles of sorted at once.
+func (m QuantileMethod) Quantiles(sorted []time.Duration, qs ...float64) []time.Duration {
+	out := make([]time.Duration, len(qs))
+	for i, q := range qs {
+		out[i] = m.Quantile(sorted, q)
+	}
+	return out
+}
+
+func harrellDavis(sorted []time.Duration, q float64) time.Duration {
+	n := float64(len(sorted))
+	switch {
+	case len(sorted) == 1, q == 0:
+		return sorted[0]
+	case q == 1:
+		return sorted[len(sorted)-1]
+	}
+	a, b := (n+1)*q, (n+1)*(1-q)
+	var sum float64
+	prev := 0.0
+	for i, dur := range sorted {
+		cdf := stats.RegIncBeta(float64(i+1)/n, a, b)
+		sum += (cdf - prev) * float64(dur)
+		prev = cdf
+	}
+	return time.Duration(math.Round(sum))
+}
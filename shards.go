@@ -0,0 +1,38 @@
+package benchkit
+
+import "sync"
+
+// shards hands out a BenchEach of a kit to every caller of Each, so that
+// goroutines don't share one, and keeps them all to be merged on Teardown.
+// The first one is allocated in advance, so that a kit used by a single
+// goroutine doesn't allocate once the benchmark started.
+type shards[E any] struct {
+	mu    sync.Mutex
+	fresh func() E
+	first E
+	all   []E
+}
+
+func newShards[E any](fresh func() E) *shards[E] {
+	return &shards[E]{fresh: fresh, first: fresh()}
+}
+
+// next gives the shard allocated in advance on the first call, then a new
+// one on every call.
+func (s *shards[E]) next() E {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	shard := s.first
+	if len(s.all) != 0 {
+		shard = s.fresh()
+	}
+	s.all = append(s.all, shard)
+	return shard
+}
+
+// list returns the shards handed out so far.
+func (s *shards[E]) list() []E {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.all
+}
@@ -0,0 +1,218 @@
+package benchkit
+
+import (
+	"strconv"
+	"time"
+)
+
+// SpanResult contains the timings of a span benchmark. Each step is the root
+// of a tree of the spans nested in it.
+type SpanResult struct {
+	N        int
+	Setup    time.Time
+	Start    time.Time
+	Teardown time.Time
+	Each     []Span
+}
+
+// Span contains statistics about a step, or a part of a step, along with the
+// spans nested in it.
+type Span struct {
+	// Name of the span. The name of a step is its id.
+	Name     string
+	Time     TimeStep
+	Children []Span
+}
+
+// Total is the time spent in the span over the whole benchmark.
+func (s *Span) Total() time.Duration {
+	var sum time.Duration
+	for _, dur := range s.Time.all {
+		sum += dur
+	}
+	return sum
+}
+
+// Self is the part of Total that wasn't spent in any of the children.
+func (s *Span) Self() time.Duration {
+	self := s.Total()
+	for i := range s.Children {
+		self -= s.Children[i].Total()
+	}
+	if self < 0 {
+		return 0
+	}
+	return self
+}
+
+// SpanKit is a BenchKit that can break steps into nested spans.
+type SpanKit interface {
+	BenchKit
+	// Spans is like Each, but gives an object that also tracks the spans
+	// within each step.
+	Spans() SpanEach
+}
+
+// SpanEach tracks metrics about work units of your benchmark, and about
+// the parts they're made of.
+type SpanEach interface {
+	BenchEach
+	// Enter must be called _before_ starting a part of the current unit of
+	// work. Parts can be nested: entering a span while in another one makes
+	// it a child of the other. Entering a span outside of a unit of work
+	// panics.
+	Enter(name string)
+	// Exit must be called _after_ finishing the span entered last.
+	Exit()
+}
+
+type spanBenchKit struct {
+	n        int
+	setup    time.Time
+	start    time.Time
+	teardown time.Time
+
+	shards *shards[*spanEach]
+
+	results *SpanResult
+}
+
+func (s *spanBenchKit) Setup()          { s.setup = time.Now() }
+func (s *spanBenchKit) Starting()       { s.start = time.Now() }
+func (s *spanBenchKit) Each() BenchEach { return s.Spans() }
+
+func (s *spanBenchKit) Spans() SpanEach { return s.shards.next() }
+
+func (s *spanBenchKit) Teardown() {
+	s.teardown = time.Now()
+	s.results.N = s.n
+	s.results.Setup = s.setup
+	s.results.Start = s.start
+	s.results.Teardown = s.teardown
+	s.results.Each = make([]Span, s.n)
+
+	shards := s.shards.list()
+	for i := range s.results.Each {
+		root := &spanNode{name: strconv.Itoa(i)}
+		for _, shard := range shards {
+			root.merge(shard.roots[i])
+		}
+		s.results.Each[i] = root.span()
+	}
+}
+
+type spanNode struct {
+	name     string
+	start    time.Time
+	samples  []time.Duration
+	parent   *spanNode
+	children []*spanNode
+}
+
+// child finds the child span with the given name, or creates it.
+func (s *spanNode) child(name string, m int) *spanNode {
+	for _, child := range s.children {
+		if child.name == name {
+			return child
+		}
+	}
+	child := &spanNode{
+		name:    name,
+		samples: make([]time.Duration, 0, m),
+		parent:  s,
+	}
+	s.children = append(s.children, child)
+	return child
+}
+
+// merge adds the samples of the tree of other to the tree of s.
+func (s *spanNode) merge(other *spanNode) {
+	s.samples = append(s.samples, other.samples...)
+	for _, otherChild := range other.children {
+		s.child(otherChild.name, 0).merge(otherChild)
+	}
+}
+
+func (s *spanNode) span() Span {
+	span := Span{Name: s.name, Time: NewTimeStep(s.samples, DefaultFilter)}
+	for _, child := range s.children {
+		span.Children = append(span.Children, child.span())
+	}
+	return span
+}
+
+type spanEach struct {
+	m     int
+	roots []*spanNode
+	// cur is the innermost span entered in the current step.
+	cur *spanNode
+}
+
+func newSpanEach(n, m int) *spanEach {
+	each := &spanEach{m: m, roots: make([]*spanNode, n)}
+	for i := range each.roots {
+		each.roots[i] = &spanNode{
+			name:    strconv.Itoa(i),
+			samples: make([]time.Duration, 0, m),
+		}
+	}
+	return each
+}
+
+func (s *spanEach) Before(id int) {
+	s.cur = s.roots[id]
+	s.cur.start = time.Now()
+}
+
+func (s *spanEach) After(id int) {
+	root := s.roots[id]
+	if root.start.IsZero() {
+		panic("benchkit: After called without a matching Before on the same BenchEach")
+	}
+	root.samples = append(root.samples, time.Since(root.start))
+	s.cur = nil
+}
+
+func (s *spanEach) Enter(name string) {
+	if s.cur == nil {
+		panic("benchkit: Enter called outside of a step, without a matching Before")
+	}
+	s.cur = s.cur.child(name, s.m)
+	s.cur.start = time.Now()
+}
+
+func (s *spanEach) Exit() {
+	end := time.Now()
+	if s.cur == nil || s.cur.parent == nil {
+		panic("benchkit: Exit called without a matching Enter")
+	}
+	s.cur.samples = append(s.cur.samples, end.Sub(s.cur.start))
+	s.cur = s.cur.parent
+}
+
+// Spans will track timings over exactly n steps, m times for each step,
+// like Time does. In addition, the SpanEach of the kit breaks steps into
+// nested spans, which are timed as well:
+//
+//	each := kit.Spans()
+//	each.Before(i)
+//	each.Enter("read")
+//	read()
+//	each.Exit()
+//	each.Enter("decode")
+//	decode()
+//	each.Exit()
+//	each.After(i)
+//
+// Memory for m times is allocated in advance for each step, and for each
+// span when it's first entered.
+func Spans(n, m int) (SpanKit, *SpanResult) {
+	bench := &spanBenchKit{
+		n:       n,
+		results: &SpanResult{},
+		shards: newShards(func() *spanEach {
+			return newSpanEach(n, m)
+		}),
+	}
+	return bench, bench.results
+}
@@ -14,144 +14,393 @@ type TimeResult struct {
 	Start    time.Time
 	Teardown time.Time
 	Each     []TimeStep
+	// Names of the steps, if they were named.
+	Names []string
+	// Reallocs counts the times memory was allocated during the benchmark,
+	// because more steps or samples were seen than allocated in advance.
+	Reallocs int
 }
 
-// TimeStep contains statistics about a step of the benchmark.
+// Index returns the position of the named step in Each, or -1.
+func (t *TimeResult) Index(name string) int {
+	return indexOf(t.Names, name)
+}
+
+// StepName returns the name of the i-th step, or its index if the steps
+// aren't named.
+func (t *TimeResult) StepName(i int) string {
+	return stepName(t.Names, i)
+}
+
+// Pooled returns statistics about the durations of all the steps, as if
+// they were a single step. If any step is a histogram, the durations are
+// pooled in a histogram of the precision of the first one.
+func (t *TimeResult) Pooled() TimeStep {
+	for i := range t.Each {
+		if t.Each[i].hist == nil {
+			continue
+		}
+		pooled := &histogram{subBits: t.Each[i].hist.subBits}
+		for j := range t.Each {
+			pooled.add(&t.Each[j])
+		}
+		return newHistStep(pooled)
+	}
+	var all []time.Duration
+	for _, step := range t.Each {
+		all = append(all, step.all...)
+	}
+	filter := DefaultFilter
+	if len(t.Each) != 0 && t.Each[0].filter != nil {
+		filter = t.Each[0].filter
+	}
+	if _, saved := filter.(savedWindow); saved {
+		// the saved window of a step doesn't fit the others
+		filter = DefaultFilter
+	}
+	return NewTimeStep(all, filter)
+}
+
+// TimeStep contains statistics about a step of the benchmark. Min, Max, Avg
+// and SD are about the Significant durations of the step, which are selected
+// by the Filter of the kit.
 type TimeStep struct {
 	all         []time.Duration
+	hist        *histogram
+	filter      Filter
 	Significant []time.Duration
 	Min         time.Duration
 	Max         time.Duration
 	Avg         time.Duration
 	SD          time.Duration
+	// Low and High are the durations left out of Significant, below and
+	// above it.
+	Low  Outliers
+	High Outliers
+}
+
+// Count is the number of times the step was measured.
+func (t *TimeStep) Count() int {
+	if t.hist != nil {
+		return int(t.hist.count)
+	}
+	return len(t.all)
 }
 
 // µ is the expected value. Greek letters because we can.
-func (t *TimeStep) µ() time.Duration {
+func µ(durs []time.Duration) time.Duration {
 	// since all values are equaly probable, µ is sum/length
 	var sum time.Duration
-	for _, dur := range t.Significant {
+	for _, dur := range durs {
 		sum += dur
 	}
-	return sum / time.Duration(len(t.Significant))
+	return sum / time.Duration(len(durs))
 }
 
 // σ is the standard deviation. Greek letters because we can.
-func (t *TimeStep) σ() time.Duration {
-	var sum time.Duration
-	for _, dur := range t.Significant {
-		sum += ((dur - t.µ()) * (dur - t.µ()))
+func σ(durs []time.Duration) time.Duration {
+	µ := µ(durs)
+	var sum float64
+	for _, dur := range durs {
+		sum += float64(dur-µ) * float64(dur-µ)
 	}
-	scaled := sum / time.Duration(len(t.Significant))
+	scaled := sum / float64(len(durs))
 
-	σ := math.Sqrt(float64(scaled))
+	σ := math.Sqrt(scaled)
 
 	return time.Duration(σ)
 }
 
-// P returns the percentile duration of the step, such as p50, p90, p99...
-func (t *TimeStep) P(factor float64) time.Duration {
-	if len(t.all) == 0 {
-		return time.Duration(0)
+// Summary of all the durations of a step, outliers included.
+type Summary struct {
+	Count int
+	Min   time.Duration
+	P50   time.Duration
+	P90   time.Duration
+	P99   time.Duration
+	Max   time.Duration
+	Mean  time.Duration
+	SD    time.Duration
+}
+
+// Summary returns statistics about all the durations of the step, unlike
+// its Min, Max, Avg and SD which only consider the significant ones.
+func (t *TimeStep) Summary() Summary {
+	s := Summary{Count: t.Count()}
+	if s.Count == 0 {
+		return s
+	}
+	p := t.Quantiles(0, 0.5, 0.9, 0.99, 1)
+	s.Min, s.P50, s.P90, s.P99, s.Max = p[0], p[1], p[2], p[3], p[4]
+	if t.hist != nil {
+		// none of the durations of a histogram are filtered out
+		s.Mean, s.SD = t.Avg, t.SD
+		return s
 	}
-	pIdx := pIndex(len(t.all), factor)
-	return t.all[pIdx-1]
+	s.Mean, s.SD = µ(t.all), σ(t.all)
+	return s
 }
 
-// PRange returns the percentile duration of the step, such as p50, p90, p99...
-func (t *TimeStep) PRange(from, to float64) []time.Duration {
-	if len(t.all) == 0 {
-		return t.all
+// Samples returns all the durations of the step, in increasing order.
+// The slice must not be modified. Steps of a HistTime kit have no samples.
+func (t *TimeStep) Samples() []time.Duration { return t.all }
+
+// Quantile returns the q-th quantile of the durations of the step, with
+// 0 <= q <= 1, interpolating linearly between the closest durations. Use
+// a QuantileMethod on the Samples of the step for other estimates.
+func (t *TimeStep) Quantile(q float64) time.Duration {
+	if t.hist != nil {
+		return t.hist.quantile(q)
 	}
-	fromIdx := pIndex(len(t.all), from)
-	toIdx := pIndex(len(t.all), to)
-	return t.all[fromIdx-1 : toIdx]
+	return Linear.Quantile(t.all, q)
 }
 
-func pIndex(base int, factor float64) int {
-	power := math.Log10(factor)
-	closest := 10 * math.Pow10(int(power))
-	idx := int(math.Ceil((factor * float64(base)) / closest))
-	return idx
+// Quantiles returns many quantiles of the durations of the step at once,
+// like Quantile.
+func (t *TimeStep) Quantiles(qs ...float64) []time.Duration {
+	out := make([]time.Duration, len(qs))
+	for i, q := range qs {
+		out[i] = t.Quantile(q)
+	}
+	return out
+}
+
+// P returns the percentile duration of the step, such as P(50), P(90),
+// P(99)... The factor is a percentage, from 0 to 100.
+//
+// Deprecated: use Quantile, which takes a fraction from 0 to 1.
+func (t *TimeStep) P(factor float64) time.Duration {
+	if t.hist != nil {
+		return t.hist.quantile(factor / 100)
+	}
+	return NearestRank.Quantile(t.all, factor/100)
+}
+
+// PRange returns the durations of the step between two percentiles, such
+// as PRange(50, 99). The percentiles are percentages, from 0 to 100.
+func (t *TimeStep) PRange(from, to float64) []time.Duration {
+	n := float64(len(t.all))
+	fromIdx := clamp(int(math.Floor(from/100*n)), 0, len(t.all))
+	toIdx := clamp(int(math.Ceil(to/100*n)), fromIdx, len(t.all))
+	return t.all[fromIdx:toIdx]
 }
 
 type timeBenchKit struct {
 	n        int
+	grow     bool
+	filter   Filter
 	setup    time.Time
 	start    time.Time
 	teardown time.Time
-	each     *timeEach
+
+	shards *shards[*timeEach]
 
 	results *TimeResult
 }
 
-func (t *timeBenchKit) Setup()          { t.setup = time.Now() }
-func (t *timeBenchKit) Starting()       { t.start = time.Now() }
-func (t *timeBenchKit) Each() BenchEach { return t.each }
+func (t *timeBenchKit) Setup()    { t.setup = time.Now() }
+func (t *timeBenchKit) Starting() { t.start = time.Now() }
+
+func (t *timeBenchKit) Each() BenchEach { return t.shards.next() }
+
 func (t *timeBenchKit) Teardown() {
 	t.teardown = time.Now()
-	t.results.N = t.n
+	merged, reallocs := t.merge()
+	t.results.N = len(merged)
 	t.results.Setup = t.setup
 	t.results.Start = t.start
 	t.results.Teardown = t.teardown
-	t.results.Each = make([]TimeStep, len(t.each.after))
-	for i, after := range t.each.after {
-		d := durationSlice(after)
-		sort.Sort(&d)
-		step := TimeStep{all: d}
-		step.Significant = step.PRange(0.5, 0.95)
-		step.Min = step.Significant[0]
-		step.Max = step.Significant[len(step.Significant)-1]
-		step.Avg = step.µ()
-		step.SD = step.σ()
-		t.results.Each[i] = step
+	t.results.Reallocs = reallocs
+	t.results.Each = make([]TimeStep, len(merged))
+	for i, after := range merged {
+		t.results.Each[i] = NewTimeStep(after, t.filter)
+	}
+}
+
+// NewTimeStep computes the statistics of a step from its durations, over
+// the ones kept by filter, or by the DefaultFilter if nil. The durations
+// are sorted in place and kept by the step.
+func NewTimeStep(durs []time.Duration, filter Filter) TimeStep {
+	if filter == nil {
+		filter = DefaultFilter
+	}
+	d := durationSlice(durs)
+	sort.Sort(&d)
+	step := TimeStep{all: d, filter: filter}
+
+	lo, hi, low, high := filter.Window(d)
+	lo, hi = clamp(lo, 0, len(d)), clamp(hi, 0, len(d))
+	if hi < lo {
+		hi = lo
+	}
+	step.Significant = d[lo:hi]
+	if lo > 0 {
+		step.Low = Outliers{Durations: d[:lo], Reason: low}
+	}
+	if hi < len(d) {
+		step.High = Outliers{Durations: d[hi:], Reason: high}
+	}
+	if len(step.Significant) == 0 {
+		// the step never ran
+		return step
 	}
+	step.Min = step.Significant[0]
+	step.Max = step.Significant[len(step.Significant)-1]
+	step.Avg = µ(step.Significant)
+	step.SD = σ(step.Significant)
+	return step
+}
+
+// merge concatenates the durations recorded by all the shards, and counts
+// their reallocations.
+func (t *timeBenchKit) merge() ([][]time.Duration, int) {
+	shards := t.shards.list()
+	if len(shards) == 1 {
+		shard := shards[0]
+		return shard.after[:shard.steps], shard.reallocs
+	}
+	var merged [][]time.Duration
+	if !t.grow {
+		merged = make([][]time.Duration, t.n)
+	}
+	reallocs := 0
+	for _, shard := range shards {
+		for len(merged) < shard.steps {
+			merged = append(merged, nil)
+		}
+		for i, after := range shard.after[:shard.steps] {
+			merged[i] = append(merged[i], after...)
+		}
+		reallocs += shard.reallocs
+	}
+	return merged, reallocs
 }
 
 type timeEach struct {
-	before [][]time.Time
+	m        int
+	grow     bool
+	reallocs int
+	// steps is the number of steps tracked, which grows with the
+	// highest id seen if the kit grows.
+	steps int
+	// before is the last start of each step.
+	before []time.Time
 	after  [][]time.Duration
 }
 
+func newTimeEach(n, m int, grow bool) *timeEach {
+	each := &timeEach{
+		m:      m,
+		grow:   grow,
+		steps:  n,
+		before: make([]time.Time, n),
+		after:  make([][]time.Duration, n),
+	}
+	for i := 0; i < n; i++ {
+		each.after[i] = make([]time.Duration, 0, m)
+	}
+	if grow {
+		each.steps = 0
+	}
+	return each
+}
+
+// growTo makes room for step id, if the kit grows.
+func (t *timeEach) growTo(id int) {
+	if !t.grow {
+		return
+	}
+	if id >= t.steps {
+		t.steps = id + 1
+	}
+	if id < len(t.after) {
+		return
+	}
+	n := 2 * len(t.after)
+	if n <= id {
+		n = id + 1
+	}
+	before := make([]time.Time, n)
+	copy(before, t.before)
+	after := make([][]time.Duration, n)
+	copy(after, t.after)
+	for i := len(t.after); i < n; i++ {
+		after[i] = make([]time.Duration, 0, t.m)
+	}
+	t.before, t.after = before, after
+	t.reallocs++
+}
+
+// record adds a duration to a step, counting the reallocation of the
+// step if it's full.
+func (t *timeEach) record(id int, dur time.Duration) {
+	t.growTo(id)
+	if len(t.after[id]) == cap(t.after[id]) {
+		t.reallocs++
+	}
+	t.after[id] = append(t.after[id], dur)
+}
+
 func (t *timeEach) Before(id int) {
-	t.before[id] = append(t.before[id], time.Now())
+	t.growTo(id)
+	t.before[id] = time.Now()
 }
 func (t *timeEach) After(id int) {
-	beforeIdx := max(len(t.before[id])-1, 0)
-	before := t.before[id][beforeIdx]
-	t.after[id] = append(t.after[id], time.Since(before))
+	end := time.Now()
+	t.growTo(id)
+	if t.before[id].IsZero() {
+		panic("benchkit: After called without a matching Before on the same BenchEach")
+	}
+	t.record(id, end.Sub(t.before[id]))
+}
+
+func (t *timeEach) Begin(id int) Token {
+	return Token{id: id, start: time.Now()}
+}
+
+func (t *timeEach) End(tok Token) {
+	t.record(tok.id, time.Since(tok.start))
+}
+
+func clamp(v, lo, hi int) int {
+	if v < lo {
+		return lo
+	}
+	if v > hi {
+		return hi
+	}
+	return v
 }
 
 // Time will track timings over exactly n steps, m times for each step.
 // Memory is allocated in advance for m times per step, but you can record
 // less than m times without effect, or more than m times with a loss of
-// precision (due to extra allocation).
-func Time(n, m int) (BenchKit, *TimeResult) {
-
+// precision (due to extra allocation). The number of such reallocations
+// is found in the Reallocs of the result.
+//
+// With the Grow option, more than n steps can be tracked, at the cost of
+// reallocations when a step beyond the ones allocated in advance is seen.
+//
+// The statistics of each step only consider the durations kept by the
+// DefaultFilter, unless another one is given with the WithFilter option.
+//
+// The kit is safe for concurrent use as long as each goroutine calls
+// Each to get its own BenchEach. The samples of all of them are merged
+// on Teardown.
+func Time(n, m int, opts ...Option) (BenchKit, *TimeResult) {
+	o := newOptions(opts)
 	bench := &timeBenchKit{
-		n: n,
-		each: &timeEach{
-			before: make([][]time.Time, n),
-			after:  make([][]time.Duration, n),
-		},
+		n:       n,
+		grow:    o.grow,
+		filter:  o.filter,
 		results: &TimeResult{},
+		shards: newShards(func() *timeEach {
+			return newTimeEach(n, m, o.grow)
+		}),
 	}
-
-	for i := 0; i < n; i++ {
-		bench.each.before[i] = make([]time.Time, 0, m)
-		bench.each.after[i] = make([]time.Duration, 0, m)
-	}
-
 	return bench, bench.results
 }
 
-func max(a, b int) int {
-	if a > b {
-		return a
-	}
-	return b
-}
-
 type durationSlice []time.Duration
 
 func (d *durationSlice) Len() int           { return len(*d) }
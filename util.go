@@ -1,8 +1,34 @@
 package benchkit
 
-// BUG(aybabtme): not sure I like the Bench func.
+// Run is a helper func that runs a benchmark with kit, calling its methods
+// in order for you:
+//
+//   - Setup, then setup, which prepares the benchmark data.
+//   - Starting, then body, which does the work to benchmark.
+//   - Teardown, even if setup or body fail or panic.
+//
+// The result given with kit is returned once populated by Teardown, along
+// with the error of setup or body, if any. A nil setup is skipped.
+func Run[R any](kit BenchKit, result R, setup func() error, body func(each BenchEach) error) (R, error) {
+	err := run(kit, setup, body)
+	return result, err
+}
+
+func run(kit BenchKit, setup func() error, body func(each BenchEach) error) error {
+	kit.Setup()
+	defer kit.Teardown()
+	if setup != nil {
+		if err := setup(); err != nil {
+			return err
+		}
+	}
+	kit.Starting()
+	return body(kit.Each())
+}
 
 // Bench is a helper func that will call Starting/Teardown for you.
+//
+// Deprecated: the results of Bench must be type asserted, use Run instead.
 func Bench(kit BenchKit, results interface{}) *eacher {
 	kit.Setup()
 	kit.Starting()